@@ -0,0 +1,233 @@
+package cchooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultChildTimeout is the per-hook timeout used by an Aggregator when none is configured
+// It is well under Claude Code's 60 second default hook timeout, so that a hung child is
+// reported and the other children's results are merged before Claude Code kills the aggregator.
+const DefaultChildTimeout = 45 * time.Second
+
+// ChildHook is a hook executable run by an Aggregator
+type ChildHook struct {
+	// Name identifies the hook in messages; it defaults to Path
+	Name string
+	// Path is the executable to run
+	Path string
+	// Args are passed to the executable
+	Args []string
+	// Timeout overrides the Aggregator's timeout for this hook
+	Timeout time.Duration
+}
+
+func (h ChildHook) name() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return h.Path
+}
+
+// Aggregator runs several hook executables for the same event and merges their results
+// into a single response. Every child receives the same stdin JSON and runs in parallel;
+// the results are merged in the order the hooks are listed:
+//
+//   - Exit code 0 with JSON on stdout is decoded as the event's response. Plain text
+//     stdout is added as additional context for UserPromptSubmit and SessionStart.
+//   - Exit code 2 blocks with stderr as the reason: deny for PreToolUse, block for
+//     PostToolUse, Stop, SubagentStop and UserPromptSubmit, and a system message for
+//     events that cannot be blocked.
+//   - Any other exit code, a timeout or a failure to start is reported as a system
+//     message and does not block.
+//
+// The responses are merged with the same rules as MergePreToolUse, MergePostToolUse and
+// MergeStop. Events the SDK does not know are passed through without output.
+type Aggregator struct {
+	Hooks []ChildHook
+	// Timeout is the default per-hook timeout; zero uses DefaultChildTimeout
+	// Claude Code kills the aggregator when its own hook timeout passes, so keep this below
+	// the timeout configured for the aggregator in the settings file.
+	Timeout time.Duration
+	// Logger receives diagnostics; it defaults to the same logger as a Runner without one
+	Logger *slog.Logger
+}
+
+// childResult is the outcome of running a child hook
+type childResult struct {
+	name     string
+	exitCode int
+	stdout   []byte
+	stderr   []byte
+	err      error // set if the hook could not be run or timed out
+}
+
+// Runner returns a Runner whose Raw handler aggregates the child hooks for every event
+func (a *Aggregator) Runner() *Runner {
+	return &Runner{Raw: a.Aggregate, Logger: a.Logger}
+}
+
+// logger returns the Aggregator's Logger, or the default logger if none is set
+func (a *Aggregator) logger() *slog.Logger {
+	if a.Logger != nil {
+		return a.Logger
+	}
+	return defaultLogger()
+}
+
+// Aggregate runs the child hooks with rawJSON as their input and returns the merged response
+// It can be used as a Runner's Raw handler. It returns nil if rawJSON has no hook_event_name,
+// so the Runner reports the malformed input.
+func (a *Aggregator) Aggregate(ctx context.Context, rawJSON string) *RawResponse {
+	var envelope struct {
+		HookEventName string `json:"hook_event_name"`
+	}
+	if err := json.Unmarshal([]byte(rawJSON), &envelope); err != nil || envelope.HookEventName == "" {
+		return nil
+	}
+
+	results := a.run(ctx, []byte(rawJSON))
+	responses := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		responses = append(responses, a.childResponse(ctx, envelope.HookEventName, result))
+	}
+
+	output, err := encodeResponse(mergeResponses(envelope.HookEventName, responses))
+	if err != nil {
+		// Fall back to the Runner's handling of the event
+		a.logger().ErrorContext(ctx, "failed to encode merged response", "event", envelope.HookEventName, "error", err)
+		return nil
+	}
+	return &RawResponse{ExitCode: 0, Output: string(output)}
+}
+
+// run executes every child hook in parallel and returns the results in hook order
+func (a *Aggregator) run(ctx context.Context, input []byte) []childResult {
+	results := make([]childResult, len(a.Hooks))
+	var wg sync.WaitGroup
+	for i, hook := range a.Hooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = a.runHook(ctx, hook, input)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (a *Aggregator) runHook(ctx context.Context, hook ChildHook, input []byte) childResult {
+	timeout := hook.Timeout
+	if timeout == 0 {
+		timeout = a.Timeout
+	}
+	if timeout == 0 {
+		timeout = DefaultChildTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, hook.Path, hook.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait forever for grandchildren holding the output pipes after a timeout
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	result := childResult{name: hook.name(), stdout: stdout.Bytes(), stderr: stderr.Bytes()}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.err = fmt.Errorf("timed out after %s", timeout)
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.exitCode = exitErr.ExitCode()
+	default:
+		result.err = err
+	}
+	return result
+}
+
+// childResponse converts a child hook's result into a response for the event
+// Output that cannot be used is logged rather than reported to Claude Code.
+func (a *Aggregator) childResponse(ctx context.Context, eventName string, result childResult) interface{} {
+	stderr := strings.TrimSpace(string(result.stderr))
+
+	switch {
+	case result.err != nil:
+		return childFailure(eventName, fmt.Sprintf("hook %s failed: %v", result.name, result.err))
+
+	case result.exitCode == 0:
+		stdout := bytes.TrimSpace(result.stdout)
+		if len(stdout) == 0 {
+			return nil
+		}
+		resp := newResponse(eventName)
+		if resp == nil {
+			return nil
+		}
+		if err := json.Unmarshal(stdout, resp); err != nil {
+			// Plain text output is added as context for the events that support it
+			if eventName == "UserPromptSubmit" || eventName == "SessionStart" {
+				return WithAdditionalContext(newResponse(eventName).(additionalContextResponse), string(stdout))
+			}
+			a.logger().WarnContext(ctx, "ignoring child hook output that is not valid JSON", "event", eventName, "hook", result.name, "error", err)
+			return nil
+		}
+		return resp
+
+	case result.exitCode == 2:
+		reason := stderr
+		if reason == "" {
+			reason = fmt.Sprintf("blocked by hook %s", result.name)
+		}
+		return childBlock(eventName, reason)
+
+	default:
+		message := fmt.Sprintf("hook %s exited with code %d", result.name, result.exitCode)
+		if stderr != "" {
+			message += ": " + stderr
+		}
+		return childFailure(eventName, message)
+	}
+}
+
+// childBlock returns the blocking response for an event, as signalled by exit code 2
+func childBlock(eventName string, reason string) interface{} {
+	switch eventName {
+	case "PreToolUse":
+		return DenyTool(reason)
+	case "PostToolUse":
+		return PostBlock(reason)
+	case "Stop":
+		return BlockStop(reason)
+	case "SubagentStop":
+		return BlockSubagentStop(reason)
+	case "UserPromptSubmit":
+		return BlockPrompt(reason)
+	default:
+		// Events that cannot be blocked show the reason to the user
+		return childFailure(eventName, reason)
+	}
+}
+
+// childFailure returns a non-blocking response that shows message to the user
+func childFailure(eventName string, message string) interface{} {
+	resp := newResponse(eventName)
+	if resp == nil {
+		return nil
+	}
+	return WithSystemMessage(resp, message)
+}
@@ -0,0 +1,186 @@
+package cchooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAggregatorChild is not a real test: it is run as a child hook by the Aggregator tests
+// The behaviour is selected by the arguments after "--".
+func TestAggregatorChild(t *testing.T) {
+	if os.Getenv("CCHOOKS_AGGREGATOR_CHILD") != "1" {
+		t.Skip("only run as an aggregator child hook")
+	}
+
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+
+	input, _ := io.ReadAll(os.Stdin)
+	if !strings.Contains(string(input), `"hook_event_name"`) {
+		fmt.Fprint(os.Stderr, "missing input")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "stdout":
+		fmt.Print(args[1])
+		os.Exit(0)
+	case "exit":
+		fmt.Fprint(os.Stderr, args[2])
+		code := 0
+		fmt.Sscanf(args[1], "%d", &code)
+		os.Exit(code)
+	case "sleep":
+		time.Sleep(10 * time.Second)
+		os.Exit(0)
+	}
+	os.Exit(0)
+}
+
+func childHook(name string, args ...string) ChildHook {
+	return ChildHook{
+		Name: name,
+		Path: os.Args[0],
+		Args: append([]string{"-test.run=^TestAggregatorChild$", "--"}, args...),
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	t.Setenv("CCHOOKS_AGGREGATOR_CHILD", "1")
+
+	preToolUse := `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`
+
+	tests := []struct {
+		name       string
+		input      string
+		hooks      []ChildHook
+		wantOutput string
+		// wantContains is checked instead of wantOutput when set
+		wantContains []string
+	}{
+		{
+			name:  "all hooks allow",
+			input: preToolUse,
+			hooks: []ChildHook{
+				childHook("a", "exit", "0", ""),
+				childHook("b", "stdout", ""),
+			},
+			wantOutput: "",
+		},
+		{
+			name:  "exit code 2 denies over JSON ask",
+			input: preToolUse,
+			hooks: []ChildHook{
+				childHook("ask", "stdout", `{"hookSpecificOutput": {"hookEventName": "PreToolUse", "permissionDecision": "ask", "permissionDecisionReason": "network access"}}`),
+				childHook("deny", "exit", "2", "rm is not allowed"),
+				childHook("allow", "stdout", `{"decision": "approve"}`),
+			},
+			wantOutput: `{
+  "hookSpecificOutput": {
+    "hookEventName": "PreToolUse",
+    "permissionDecision": "deny",
+    "permissionDecisionReason": "rm is not allowed"
+  }
+}
+`,
+		},
+		{
+			name:  "failures and timeouts become system messages",
+			input: preToolUse,
+			hooks: []ChildHook{
+				childHook("crash", "exit", "1", "boom"),
+				func() ChildHook {
+					hook := childHook("slow", "sleep")
+					hook.Timeout = 100 * time.Millisecond
+					return hook
+				}(),
+				{Name: "missing", Path: "/nonexistent/hook"},
+			},
+			wantContains: []string{
+				`"systemMessage": "hook crash exited with code 1: boom\nhook slow failed: timed out after 100ms\nhook missing failed: `,
+			},
+		},
+		{
+			name:  "Stop blocks are joined",
+			input: `{"hook_event_name": "Stop", "session_id": "test", "stop_hook_active": false}`,
+			hooks: []ChildHook{
+				childHook("lint", "exit", "2", "run the linter"),
+				childHook("tests", "stdout", `{"decision": "block", "reason": "tests are failing"}`),
+			},
+			wantOutput: `{
+  "decision": "block",
+  "reason": "run the linter; tests are failing"
+}
+`,
+		},
+		{
+			name:  "plain text becomes prompt context",
+			input: `{"hook_event_name": "UserPromptSubmit", "session_id": "test", "prompt": "hi"}`,
+			hooks: []ChildHook{
+				childHook("ticket", "stdout", "Ticket: ABC-123"),
+				childHook("branch", "stdout", `{"hookSpecificOutput": {"hookEventName": "UserPromptSubmit", "additionalContext": "Branch: main"}}`),
+			},
+			wantOutput: `{
+  "hookSpecificOutput": {
+    "hookEventName": "UserPromptSubmit",
+    "additionalContext": "Ticket: ABC-123\nBranch: main"
+  }
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregator := &Aggregator{Hooks: tt.hooks}
+			resp := aggregator.Aggregate(context.Background(), tt.input)
+			if resp == nil {
+				t.Fatal("expected a response")
+			}
+			if resp.ExitCode != 0 {
+				t.Errorf("ExitCode = %d, want 0", resp.ExitCode)
+			}
+			if tt.wantContains != nil {
+				for _, want := range tt.wantContains {
+					if !strings.Contains(resp.Output, want) {
+						t.Errorf("Output %q does not contain %q", resp.Output, want)
+					}
+				}
+			} else if resp.Output != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", resp.Output, tt.wantOutput)
+			}
+		})
+	}
+
+	t.Run("invalid child output is logged", func(t *testing.T) {
+		var buf bytes.Buffer
+		aggregator := &Aggregator{
+			Hooks:  []ChildHook{childHook("chatty", "stdout", "looks fine to me")},
+			Logger: slog.New(slog.NewJSONHandler(&buf, nil)),
+		}
+		if resp := aggregator.Aggregate(context.Background(), preToolUse); resp == nil || resp.Output != "" {
+			t.Errorf("expected an empty response, got %+v", resp)
+		}
+		if !strings.Contains(buf.String(), "not valid JSON") || !strings.Contains(buf.String(), `"hook":"chatty"`) {
+			t.Errorf("log = %s", buf.String())
+		}
+	})
+
+	t.Run("invalid input falls through to the Runner", func(t *testing.T) {
+		if resp := (&Aggregator{}).Aggregate(context.Background(), "{not json"); resp != nil {
+			t.Errorf("expected nil, got %+v", resp)
+		}
+	})
+}
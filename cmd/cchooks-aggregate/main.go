@@ -0,0 +1,48 @@
+// Command cchooks-aggregate runs several hook executables for the same Claude Code
+// event and merges their results into a single response.
+//
+// Usage:
+//
+//	cchooks-aggregate [-timeout 45s] "security-hook --strict" ./lint-hook ...
+//
+// Each argument is a hook command line, split on whitespace. Every hook receives the
+// same stdin JSON and runs in parallel. See cchooks.Aggregator for how exit codes and
+// outputs are merged.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	cchooks "github.com/brads3290/cchooks"
+)
+
+func main() {
+	timeout := flag.Duration("timeout", cchooks.DefaultChildTimeout, "timeout for each child hook")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [-timeout duration] hook-command...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	aggregator := &cchooks.Aggregator{Timeout: *timeout}
+	for _, arg := range flag.Args() {
+		fields := strings.Fields(arg)
+		if len(fields) == 0 {
+			continue
+		}
+		aggregator.Hooks = append(aggregator.Hooks, cchooks.ChildHook{
+			Path: fields[0],
+			Args: fields[1:],
+		})
+	}
+
+	if len(aggregator.Hooks) == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	aggregator.Runner().Run()
+}
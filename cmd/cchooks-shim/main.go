@@ -0,0 +1,89 @@
+// Command cchooks-shim forwards a Claude Code hook invocation to a hook daemon, so that
+// hooks with expensive setup do not pay for it on every tool call.
+//
+// Usage:
+//
+//	cchooks-shim -name policy-hook [-timeout 45s] [fallback-command [args...]]
+//	cchooks-shim -socket path [-timeout 45s] [fallback-command [args...]]
+//
+// -name uses the socket at cchooks.SocketPath(name), in a directory private to the current
+// user. The shim sends stdin to the daemon listening on the socket (see Runner.ListenAndServe)
+// and relays its stdout, stderr and exit code. If no daemon is running, the fallback
+// command is run with the same stdin instead, typically the hook binary itself, which
+// handles the event in its own process. Without a fallback command the shim exits
+// with code 1, which Claude Code treats as a non-blocking error.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	cchooks "github.com/brads3290/cchooks"
+)
+
+func main() {
+	socket := flag.String("socket", "", "path of the hook daemon's Unix domain socket")
+	name := flag.String("name", "", "name of the hook daemon; the socket is cchooks.SocketPath(name)")
+	timeout := flag.Duration("timeout", cchooks.DefaultChildTimeout, "timeout for the daemon's response")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s -name name | -socket path [-timeout duration] [fallback-command [args...]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if (*socket == "") == (*name == "") {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *name != "" {
+		*socket = cchooks.SocketPath(*name)
+	}
+
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read stdin: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	result, err := cchooks.Forward(ctx, *socket, input)
+	if errors.Is(err, cchooks.ErrDaemonUnavailable) && flag.NArg() > 0 {
+		os.Exit(runFallback(flag.Args(), input))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Fprint(os.Stdout, result.Stdout)
+	fmt.Fprint(os.Stderr, result.Stderr)
+	os.Exit(result.ExitCode)
+}
+
+// runFallback runs the fallback hook command with the input and returns its exit code
+func runFallback(args []string, input []byte) int {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode()
+	default:
+		fmt.Fprintf(os.Stderr, "failed to run fallback hook: %v\n", err)
+		return 1
+	}
+}
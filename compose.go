@@ -0,0 +1,329 @@
+package cchooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ComposePreToolUse combines several PreToolUse handlers into one
+// Every handler is called in order and the responses are merged with MergePreToolUse.
+//
+//	runner := &cchooks.Runner{
+//	    PreToolUse: cchooks.ComposePreToolUse(securityPolicy, platformPolicy, projectPolicy),
+//	}
+func ComposePreToolUse(handlers ...func(context.Context, *PreToolUseEvent) PreToolUseResponseInterface) func(context.Context, *PreToolUseEvent) PreToolUseResponseInterface {
+	return func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+		responses := make([]PreToolUseResponseInterface, 0, len(handlers))
+		for _, handler := range handlers {
+			responses = append(responses, handler(ctx, event))
+		}
+		return MergePreToolUse(responses...)
+	}
+}
+
+// ComposePostToolUse combines several PostToolUse handlers into one
+// Every handler is called in order and the responses are merged with MergePostToolUse.
+func ComposePostToolUse(handlers ...func(context.Context, *PostToolUseEvent) PostToolUseResponseInterface) func(context.Context, *PostToolUseEvent) PostToolUseResponseInterface {
+	return func(ctx context.Context, event *PostToolUseEvent) PostToolUseResponseInterface {
+		responses := make([]PostToolUseResponseInterface, 0, len(handlers))
+		for _, handler := range handlers {
+			responses = append(responses, handler(ctx, event))
+		}
+		return MergePostToolUse(responses...)
+	}
+}
+
+// ComposeStop combines several Stop handlers into one
+// Every handler is called in order and the responses are merged with MergeStop.
+func ComposeStop(handlers ...func(context.Context, *StopEvent) StopResponseInterface) func(context.Context, *StopEvent) StopResponseInterface {
+	return func(ctx context.Context, event *StopEvent) StopResponseInterface {
+		responses := make([]StopResponseInterface, 0, len(handlers))
+		for _, handler := range handlers {
+			responses = append(responses, handler(ctx, event))
+		}
+		return MergeStop(responses...)
+	}
+}
+
+// MergePreToolUse merges PreToolUse responses into a single response
+// The permission decision with the highest precedence wins: deny, then ask, then allow.
+// Legacy block and approve decisions count as deny and allow. The reasons of every
+// response with the winning decision are joined. An updated input is kept unless the
+// merged decision is deny; if several responses update the input, the first wins.
+// Common fields are merged as described in MergePostToolUse. If any response is an
+// error, the errors are joined and returned as an *ErrorResponse.
+func MergePreToolUse(responses ...PreToolUseResponseInterface) PreToolUseResponseInterface {
+	var m responseMerger
+	var decision string
+	var reasons []string
+	var updatedInput json.RawMessage
+
+	for _, response := range responses {
+		switch resp := response.(type) {
+		case *ErrorResponse:
+			m.errs = append(m.errs, resp.Error)
+		case *PreToolUseResponse:
+			m.add(resp.Continue, resp.StopReason, &resp.CommonOutput)
+
+			respDecision := resp.PermissionDecision()
+			if permissionRank(respDecision) > permissionRank(decision) {
+				decision = respDecision
+				reasons = nil
+			}
+			if respDecision != "" && respDecision == decision && resp.PermissionReason() != "" {
+				reasons = append(reasons, resp.PermissionReason())
+			}
+
+			if updatedInput == nil {
+				updatedInput = resp.UpdatedInput()
+			}
+		}
+	}
+
+	if len(m.errs) > 0 {
+		return Error(errors.Join(m.errs...))
+	}
+
+	merged := &PreToolUseResponse{}
+	if decision != "" {
+		out := hookSpecificOutputFor(merged)
+		out.PermissionDecision = decision
+		out.PermissionDecisionReason = strings.Join(reasons, "; ")
+	}
+	if updatedInput != nil && decision != PermissionDecisionDeny {
+		hookSpecificOutputFor(merged).UpdatedInput = updatedInput
+	}
+	m.apply(merged, &merged.Continue, &merged.StopReason)
+	return merged
+}
+
+// MergePostToolUse merges PostToolUse responses into a single response
+// A block decision wins over no decision and the reasons of every blocking response
+// are joined. For the common fields, continue is false if any response sets it to
+// false, stop reasons, system messages and additional context are joined, and output
+// is suppressed if any response suppresses it. A block is emitted with exit code 2 if
+// any response uses WithExitCodeBlock. If any response is an error, the errors are
+// joined and returned as an *ErrorResponse.
+func MergePostToolUse(responses ...PostToolUseResponseInterface) PostToolUseResponseInterface {
+	var m responseMerger
+	for _, response := range responses {
+		switch resp := response.(type) {
+		case *ErrorResponse:
+			m.errs = append(m.errs, resp.Error)
+		case *PostToolUseResponse:
+			m.add(resp.Continue, resp.StopReason, &resp.CommonOutput)
+			m.addDecision(resp.Decision, resp.Reason)
+		}
+	}
+
+	if len(m.errs) > 0 {
+		return Error(errors.Join(m.errs...))
+	}
+
+	merged := &PostToolUseResponse{}
+	merged.Decision, merged.Reason = m.decision()
+	m.apply(merged, &merged.Continue, &merged.StopReason)
+	return merged
+}
+
+// MergeStop merges Stop responses into a single response
+// A block decision wins over no decision and the reasons of every blocking response
+// are joined, so Claude receives every instruction. Common fields are merged as
+// described in MergePostToolUse. If any response is an error, the errors are joined
+// and returned as an *ErrorResponse.
+func MergeStop(responses ...StopResponseInterface) StopResponseInterface {
+	var m responseMerger
+	for _, response := range responses {
+		switch resp := response.(type) {
+		case *ErrorResponse:
+			m.errs = append(m.errs, resp.Error)
+		case *StopResponse:
+			m.add(resp.Continue, resp.StopReason, &resp.CommonOutput)
+			m.addDecision(resp.Decision, resp.Reason)
+		}
+	}
+
+	if len(m.errs) > 0 {
+		return Error(errors.Join(m.errs...))
+	}
+
+	merged := &StopResponse{}
+	merged.Decision, merged.Reason = m.decision()
+	m.apply(merged, &merged.Continue, &merged.StopReason)
+	return merged
+}
+
+// mergeResponses merges responses for any event using the same rules as the Merge functions
+// Events with a block decision follow MergeStop and events without a decision merge only
+// the common fields. Responses for other events are ignored.
+func mergeResponses(eventName string, responses []interface{}) interface{} {
+	switch eventName {
+	case "PreToolUse":
+		typed := make([]PreToolUseResponseInterface, 0, len(responses))
+		for _, response := range responses {
+			if resp, ok := response.(PreToolUseResponseInterface); ok {
+				typed = append(typed, resp)
+			}
+		}
+		return MergePreToolUse(typed...)
+	case "PostToolUse":
+		typed := make([]PostToolUseResponseInterface, 0, len(responses))
+		for _, response := range responses {
+			if resp, ok := response.(PostToolUseResponseInterface); ok {
+				typed = append(typed, resp)
+			}
+		}
+		return MergePostToolUse(typed...)
+	case "Stop":
+		typed := make([]StopResponseInterface, 0, len(responses))
+		for _, response := range responses {
+			if resp, ok := response.(StopResponseInterface); ok {
+				typed = append(typed, resp)
+			}
+		}
+		return MergeStop(typed...)
+	}
+
+	var m responseMerger
+	for _, response := range responses {
+		switch resp := response.(type) {
+		case *ErrorResponse:
+			m.errs = append(m.errs, resp.Error)
+		case *SubagentStopResponse:
+			m.add(resp.Continue, resp.StopReason, &resp.CommonOutput)
+			m.addDecision(resp.Decision, resp.Reason)
+		case *PreCompactResponse:
+			m.add(resp.Continue, resp.StopReason, &resp.CommonOutput)
+		case *UserPromptSubmitResponse:
+			m.add(resp.Continue, resp.StopReason, &resp.CommonOutput)
+			m.addDecision(resp.Decision, resp.Reason)
+		case *NotificationResponse:
+			m.add(resp.Continue, resp.StopReason, &resp.CommonOutput)
+		case *SessionStartResponse:
+			m.add(resp.Continue, resp.StopReason, &resp.CommonOutput)
+		case *SessionEndResponse:
+			m.add(resp.Continue, resp.StopReason, &resp.CommonOutput)
+		}
+	}
+
+	if len(m.errs) > 0 {
+		return Error(errors.Join(m.errs...))
+	}
+
+	switch eventName {
+	case "SubagentStop":
+		merged := &SubagentStopResponse{}
+		merged.Decision, merged.Reason = m.decision()
+		m.apply(merged, &merged.Continue, &merged.StopReason)
+		return merged
+	case "PreCompact":
+		merged := &PreCompactResponse{}
+		m.apply(merged, &merged.Continue, &merged.StopReason)
+		return merged
+	case "UserPromptSubmit":
+		merged := &UserPromptSubmitResponse{}
+		merged.Decision, merged.Reason = m.decision()
+		m.apply(merged, &merged.Continue, &merged.StopReason)
+		return merged
+	case "Notification":
+		merged := &NotificationResponse{}
+		m.apply(merged, &merged.Continue, &merged.StopReason)
+		return merged
+	case "SessionStart":
+		merged := &SessionStartResponse{}
+		m.apply(merged, &merged.Continue, &merged.StopReason)
+		return merged
+	case "SessionEnd":
+		merged := &SessionEndResponse{}
+		m.apply(merged, &merged.Continue, &merged.StopReason)
+		return merged
+	default:
+		return nil
+	}
+}
+
+// permissionRank orders permission decisions by precedence
+func permissionRank(decision string) int {
+	switch decision {
+	case PermissionDecisionDeny:
+		return 3
+	case PermissionDecisionAsk:
+		return 2
+	case PermissionDecisionAllow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// responseMerger accumulates the fields shared by every response type
+type responseMerger struct {
+	errs           []error
+	blocked        bool
+	reasons        []string
+	stop           bool
+	stopReasons    []string
+	suppressOutput bool
+	systemMessages []string
+	contexts       []string
+	exitCodeBlock  bool
+}
+
+func (m *responseMerger) add(cont *bool, stopReason string, common *CommonOutput) {
+	if cont != nil && !*cont {
+		m.stop = true
+	}
+	if stopReason != "" {
+		m.stopReasons = append(m.stopReasons, stopReason)
+	}
+	if common.SuppressOutput {
+		m.suppressOutput = true
+	}
+	if common.exitCodeBlock {
+		m.exitCodeBlock = true
+	}
+	if common.SystemMessage != "" {
+		m.systemMessages = append(m.systemMessages, common.SystemMessage)
+	}
+	if common.HookSpecificOutput != nil && common.HookSpecificOutput.AdditionalContext != "" {
+		m.contexts = append(m.contexts, common.HookSpecificOutput.AdditionalContext)
+	}
+}
+
+// addDecision records a legacy block decision and its reason
+func (m *responseMerger) addDecision(decision, reason string) {
+	if decision != "block" {
+		return
+	}
+	m.blocked = true
+	if reason != "" {
+		m.reasons = append(m.reasons, reason)
+	}
+}
+
+// decision returns the merged block decision and the joined reasons of every blocking response
+func (m *responseMerger) decision() (string, string) {
+	if !m.blocked {
+		return "", ""
+	}
+	return "block", strings.Join(m.reasons, "; ")
+}
+
+func (m *responseMerger) apply(resp commonOutputResponse, cont **bool, stopReason *string) {
+	if m.stop {
+		stop := false
+		*cont = &stop
+	}
+	*stopReason = strings.Join(m.stopReasons, "; ")
+
+	common := resp.commonOutput()
+	common.SuppressOutput = m.suppressOutput
+	common.exitCodeBlock = m.exitCodeBlock
+	common.SystemMessage = strings.Join(m.systemMessages, "\n")
+	// Other events have no hookSpecificOutput to carry the context
+	if ctxResp, ok := resp.(additionalContextResponse); ok && len(m.contexts) > 0 {
+		hookSpecificOutputFor(ctxResp).AdditionalContext = strings.Join(m.contexts, "\n")
+	}
+}
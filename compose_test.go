@@ -0,0 +1,200 @@
+package cchooks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMergePreToolUse(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []PreToolUseResponseInterface
+		wantDecision string
+		wantReason   string
+	}{
+		{
+			name:         "no responses",
+			responses:    nil,
+			wantDecision: "",
+		},
+		{
+			name:         "allow only",
+			responses:    []PreToolUseResponseInterface{AllowTool("ok"), nil, &PreToolUseResponse{}},
+			wantDecision: PermissionDecisionAllow,
+			wantReason:   "ok",
+		},
+		{
+			name:         "ask wins over allow",
+			responses:    []PreToolUseResponseInterface{AllowTool("fine"), AskUser("force push"), Approve()},
+			wantDecision: PermissionDecisionAsk,
+			wantReason:   "force push",
+		},
+		{
+			name:         "deny wins over ask and reasons are joined",
+			responses:    []PreToolUseResponseInterface{DenyTool("security"), AskUser("platform"), Block("project")},
+			wantDecision: PermissionDecisionDeny,
+			wantReason:   "security; project",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, ok := MergePreToolUse(tt.responses...).(*PreToolUseResponse)
+			if !ok {
+				t.Fatalf("expected *PreToolUseResponse")
+			}
+			if got := resp.PermissionDecision(); got != tt.wantDecision {
+				t.Errorf("PermissionDecision() = %q, want %q", got, tt.wantDecision)
+			}
+			if got := resp.PermissionReason(); got != tt.wantReason {
+				t.Errorf("PermissionReason() = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+
+	t.Run("updated input is dropped when denied", func(t *testing.T) {
+		resp := MergePreToolUse(UpdateInput(&BashInput{Command: "ls"}, ""), DenyTool("no")).(*PreToolUseResponse)
+		if resp.UpdatedInput() != nil {
+			t.Errorf("UpdatedInput() = %s, want nil", resp.UpdatedInput())
+		}
+
+		resp = MergePreToolUse(AllowTool(""), WithUpdatedInput(AllowTool(""), &BashInput{Command: "ls"})).(*PreToolUseResponse)
+		if resp.UpdatedInput() == nil || resp.PermissionDecision() != PermissionDecisionAllow {
+			t.Error("expected updated input to be kept when allowed")
+		}
+
+		resp = MergePreToolUse(AllowTool(""), UpdateInput(&BashInput{Command: "ls"}, "")).(*PreToolUseResponse)
+		if resp.UpdatedInput() == nil || resp.PermissionDecision() != PermissionDecisionAsk {
+			t.Errorf("expected updated input to be kept when asking, got %q %s", resp.PermissionDecision(), resp.UpdatedInput())
+		}
+	})
+
+	t.Run("common fields", func(t *testing.T) {
+		resp := MergePreToolUse(
+			WithSystemMessage(AllowTool(""), "first"),
+			WithSuppressOutput(WithSystemMessage(AskUser(""), "second")),
+			StopClaude("halt"),
+		).(*PreToolUseResponse)
+
+		if resp.SystemMessage != "first\nsecond" {
+			t.Errorf("SystemMessage = %q", resp.SystemMessage)
+		}
+		if !resp.SuppressOutput {
+			t.Error("expected SuppressOutput")
+		}
+		if resp.Continue == nil || *resp.Continue || resp.StopReason != "halt" {
+			t.Errorf("Continue = %v, StopReason = %q", resp.Continue, resp.StopReason)
+		}
+	})
+
+	t.Run("errors are joined", func(t *testing.T) {
+		errA, errB := errors.New("a"), errors.New("b")
+		resp, ok := MergePreToolUse(Error(errA), DenyTool("no"), Error(errB)).(*ErrorResponse)
+		if !ok {
+			t.Fatal("expected *ErrorResponse")
+		}
+		if !errors.Is(resp.Error, errA) || !errors.Is(resp.Error, errB) {
+			t.Errorf("Error = %v", resp.Error)
+		}
+	})
+}
+
+func TestMergePostToolUse(t *testing.T) {
+	resp := MergePostToolUse(
+		WithAdditionalContext(Allow(), "lint: 2 warnings"),
+		PostBlock("tests failed"),
+		WithAdditionalContext(PostBlock("coverage dropped"), "coverage: 71%"),
+	).(*PostToolUseResponse)
+
+	if resp.Decision != PostToolUseBlock || resp.Reason != "tests failed; coverage dropped" {
+		t.Errorf("Decision = %q, Reason = %q", resp.Decision, resp.Reason)
+	}
+	if resp.HookSpecificOutput == nil || resp.HookSpecificOutput.AdditionalContext != "lint: 2 warnings\ncoverage: 71%" {
+		t.Errorf("HookSpecificOutput = %+v", resp.HookSpecificOutput)
+	}
+	if resp.HookSpecificOutput.HookEventName != "PostToolUse" {
+		t.Errorf("HookEventName = %q", resp.HookSpecificOutput.HookEventName)
+	}
+
+	if !isEmpty(MergePostToolUse(Allow(), Allow())) {
+		t.Error("expected merged allow responses to be empty")
+	}
+}
+
+func TestComposeStop(t *testing.T) {
+	var order []string
+	policy := func(name string, resp StopResponseInterface) func(context.Context, *StopEvent) StopResponseInterface {
+		return func(ctx context.Context, event *StopEvent) StopResponseInterface {
+			order = append(order, name)
+			return resp
+		}
+	}
+
+	runner := &Runner{
+		Stop: ComposeStop(
+			policy("security", Continue()),
+			policy("platform", BlockStop("run the linter")),
+			policy("project", BlockStop("update the changelog")),
+		),
+	}
+
+	resp, ok := NewTestRunner(runner).TestStop(true, nil).(*StopResponse)
+	if !ok {
+		t.Fatal("expected *StopResponse")
+	}
+	if resp.Decision != StopBlock || resp.Reason != "run the linter; update the changelog" {
+		t.Errorf("Decision = %q, Reason = %q", resp.Decision, resp.Reason)
+	}
+	if strings.Join(order, ",") != "security,platform,project" {
+		t.Errorf("order = %v", order)
+	}
+}
+
+func TestMergeResponsesAdditionalContext(t *testing.T) {
+	withContext := func(resp commonOutputResponse) commonOutputResponse {
+		resp.commonOutput().HookSpecificOutput = &HookSpecificOutput{AdditionalContext: "ignored"}
+		return resp
+	}
+
+	// Stop and Notification have no hookSpecificOutput, so the context is dropped
+	for _, eventName := range []string{"Stop", "Notification", "SubagentStop", "SessionEnd"} {
+		merged, _ := mergeResponses(eventName, []interface{}{withContext(newResponse(eventName))}).(commonOutputResponse)
+		if merged == nil || merged.commonOutput().HookSpecificOutput != nil {
+			t.Errorf("%s: HookSpecificOutput = %+v, want nil", eventName, merged)
+		}
+	}
+
+	merged, ok := mergeResponses("SessionStart", []interface{}{
+		AddSessionContext("branch: main"),
+		AddSessionContext("ticket: ABC-1"),
+	}).(*SessionStartResponse)
+	if !ok || merged.HookSpecificOutput == nil || merged.HookSpecificOutput.AdditionalContext != "branch: main\nticket: ABC-1" {
+		t.Errorf("SessionStart merged = %+v", merged)
+	}
+}
+
+func TestComposePreToolUse(t *testing.T) {
+	runner := &Runner{
+		PreToolUse: ComposePreToolUse(
+			func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+				return AllowTool("")
+			},
+			func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+				if event.ToolName == "Bash" {
+					return AskUser("shell access")
+				}
+				return nil
+			},
+		),
+	}
+	tester := NewTestRunner(runner)
+
+	if err := tester.AssertPreToolUseAsks("Bash", &BashInput{Command: "ls"}); err != nil {
+		t.Error(err)
+	}
+	if err := tester.AssertPreToolUseApproves("Read", &ReadInput{FilePath: "a.txt"}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,219 @@
+package cchooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Result is the outcome of handling one hook input: what the hook process writes and its exit code
+type Result struct {
+	ExitCode int    `json:"exitCode"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+}
+
+// ErrDaemonUnavailable is returned by Forward when no daemon accepts connections on the socket
+var ErrDaemonUnavailable = errors.New("hook daemon unavailable")
+
+// ErrDaemonUntrusted is returned by Forward when the socket is served by another user's process
+// The error also wraps ErrDaemonUnavailable, since the input is not sent.
+var ErrDaemonUntrusted = errors.New("hook daemon is run by another user")
+
+// SocketPath returns the conventional socket path for the daemon called name
+// The socket is placed in a directory private to the current user: $XDG_RUNTIME_DIR/cchooks
+// when XDG_RUNTIME_DIR is set, otherwise cchooks-<uid> in the system temporary directory.
+// ListenAndServe creates the directory with mode 0700.
+func SocketPath(name string) string {
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("cchooks-%d", os.Getuid()))
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		dir = filepath.Join(runtimeDir, "cchooks")
+	}
+	return filepath.Join(dir, name+".sock")
+}
+
+// Serve handles hook inputs from connections on ln until ctx is cancelled
+// Each connection carries one hook input: the client writes the stdin JSON and closes its
+// side for writing, and the daemon replies with the Result as JSON. Forward implements
+// the client side. Connections are served concurrently, so handlers must be safe for
+// concurrent use. Serve closes ln when ctx is cancelled, waits for in-flight requests
+// and returns nil; otherwise it returns the error from Accept.
+func (r *Runner) Serve(ctx context.Context, ln net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := context.AfterFunc(ctx, func() {
+		ln.Close()
+	})
+	defer stop()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// ListenAndServe listens on the Unix domain socket at socketPath and calls Serve
+// The socket's directory must be owned by the current user and inaccessible to others
+// (mode 0700); it is created if it does not exist. SocketPath returns a suitable path.
+// A stale socket left by a daemon that exited is replaced; an error is returned if
+// another daemon is still listening or the path is not a socket. The socket is
+// removed when Serve returns.
+func (r *Runner) ListenAndServe(ctx context.Context, socketPath string) error {
+	if err := ensurePrivateDir(filepath.Dir(socketPath)); err != nil {
+		return err
+	}
+
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		return fmt.Errorf("a hook daemon is already listening on %s", socketPath)
+	}
+	if info, err := os.Lstat(socketPath); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return fmt.Errorf("%s exists and is not a socket", socketPath)
+		}
+		if err := os.Remove(socketPath); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	if err := os.Chmod(socketPath, 0o600); err != nil {
+		return err
+	}
+	return r.Serve(ctx, ln)
+}
+
+// ensurePrivateDir creates dir with mode 0700 if it does not exist, and otherwise checks
+// that only the current user can reach sockets inside it
+func ensurePrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+		info, err = os.Lstat(dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("socket directory %s is not a directory", dir)
+	}
+	// Ownership and permission bits are only meaningful on Unix
+	if uid, ok := fileOwner(info); ok {
+		if uid != os.Getuid() {
+			return fmt.Errorf("socket directory %s is owned by uid %d, not the current user", dir, uid)
+		}
+		if info.Mode().Perm()&0o077 != 0 {
+			return fmt.Errorf("socket directory %s must only be accessible by the current user (mode 0700), not %v", dir, info.Mode().Perm())
+		}
+	}
+	return nil
+}
+
+// serveConn handles the hook input on a single connection and writes back the Result
+func (r *Runner) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	result := r.Execute(ctx, conn)
+
+	// The client reports a missing or malformed response itself
+	if err := json.NewEncoder(conn).Encode(result); err != nil {
+		r.logger().ErrorContext(ctx, "failed to write daemon response", "error", err)
+	}
+}
+
+// Forward sends a hook input to the daemon listening on socketPath and returns its Result
+// If no daemon accepts the connection, the error wraps ErrDaemonUnavailable and the
+// input has not been handled, so it is safe to handle it another way. The input is
+// never sent to a daemon run by another user; the error then also wraps ErrDaemonUntrusted.
+func Forward(ctx context.Context, socketPath string, rawJSON []byte) (Result, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %w", ErrDaemonUnavailable, err)
+	}
+	defer conn.Close()
+
+	uid, ok, err := peerUID(conn.(*net.UnixConn), socketPath)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: failed to identify the daemon's user: %w", ErrDaemonUnavailable, err)
+	}
+	if ok && uid != os.Getuid() {
+		return Result{}, fmt.Errorf("%w: %w: %s is served by uid %d", ErrDaemonUnavailable, ErrDaemonUntrusted, socketPath, uid)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(rawJSON); err != nil {
+		return Result{}, fmt.Errorf("failed to send input to daemon: %w", err)
+	}
+	if err := conn.(*net.UnixConn).CloseWrite(); err != nil {
+		return Result{}, fmt.Errorf("failed to send input to daemon: %w", err)
+	}
+
+	var result Result
+	if err := json.NewDecoder(conn).Decode(&result); err != nil {
+		return Result{}, fmt.Errorf("failed to read daemon response: %w", err)
+	}
+	return result, nil
+}
+
+// ForwardOrRun reads the hook input from the Runner's stdin, forwards it to the daemon listening on
+// socketPath and relays the daemon's output and exit code. If no daemon is running,
+// the input is handled in-process, as RunContext would. Other forwarding failures are
+// reported like SDK errors, through the Error handler.
+func (r *Runner) ForwardOrRun(ctx context.Context, socketPath string) {
+	// Set default exit function if not set
+	if r.ExitFn == nil {
+		r.ExitFn = os.Exit
+	}
+
+	rawJSON, err := r.readInput(r.stdin())
+	if err != nil {
+		r.writeResult(r.errorResult(ctx, "", "", err))
+		return
+	}
+
+	result, err := Forward(ctx, socketPath, rawJSON)
+	switch {
+	case errors.Is(err, ErrDaemonUntrusted):
+		r.logger().WarnContext(ctx, "refusing to forward to hook daemon", "socket", socketPath, "error", err)
+		result = r.execute(ctx, rawJSON)
+	case errors.Is(err, ErrDaemonUnavailable):
+		r.logger().DebugContext(ctx, "hook daemon unavailable", "socket", socketPath, "error", err)
+		result = r.execute(ctx, rawJSON)
+	case err != nil:
+		eventName, _ := peekEventName(rawJSON)
+		result = r.errorResult(ctx, eventName, string(rawJSON), err)
+	}
+	r.writeResult(result)
+}
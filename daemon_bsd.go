@@ -0,0 +1,19 @@
+//go:build unix && !linux
+
+package cchooks
+
+import (
+	"net"
+	"os"
+)
+
+// peerUID returns the uid owning the socket file, which is the user that created the daemon's
+// listener. SO_PEERCRED is Linux-only.
+func peerUID(conn *net.UnixConn, socketPath string) (int, bool, error) {
+	info, err := os.Lstat(socketPath)
+	if err != nil {
+		return 0, false, err
+	}
+	uid, ok := fileOwner(info)
+	return uid, ok, nil
+}
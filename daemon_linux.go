@@ -0,0 +1,28 @@
+//go:build linux
+
+package cchooks
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerUID returns the uid of the process serving conn, from SO_PEERCRED
+func peerUID(conn *net.UnixConn, socketPath string) (int, bool, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, false, err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return 0, false, err
+	}
+	if credErr != nil {
+		return 0, false, credErr
+	}
+	return int(cred.Uid), true, nil
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package cchooks
+
+import (
+	"io/fs"
+	"net"
+)
+
+// fileOwner reports no owner; file ownership is not available on this platform
+func fileOwner(info fs.FileInfo) (int, bool) {
+	return 0, false
+}
+
+// peerUID reports no peer; peer credentials are not available on this platform
+func peerUID(conn *net.UnixConn, socketPath string) (int, bool, error) {
+	return 0, false, nil
+}
@@ -0,0 +1,324 @@
+package cchooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startDaemon serves runner on a Unix socket in a temporary directory and returns its path
+func startDaemon(t *testing.T, runner *Runner) string {
+	t.Helper()
+
+	// Unix socket paths are limited to about 100 bytes, so avoid the long t.TempDir paths
+	dir, err := os.MkdirTemp("", "cchooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "hook.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runner.ListenAndServe(ctx, socketPath)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("ListenAndServe() = %v", err)
+		}
+	})
+
+	// Wait for the daemon to listen
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return socketPath
+		}
+	}
+	t.Fatal("daemon did not start")
+	return ""
+}
+
+func TestDaemon(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	runner := &Runner{
+		PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			if bash, _ := event.AsBash(); bash != nil && strings.Contains(bash.Command, "rm -rf") {
+				return Block("dangerous command")
+			}
+			return Approve()
+		},
+		Stop: func(ctx context.Context, event *StopEvent) StopResponseInterface {
+			panic("stop failed")
+		},
+	}
+	socketPath := startDaemon(t, runner)
+
+	tests := []struct {
+		name  string
+		input string
+		want  Result
+	}{
+		{
+			name:  "response",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "rm -rf /"}}`,
+			want:  Result{Stdout: "{\n  \"decision\": \"block\",\n  \"reason\": \"dangerous command\"\n}\n"},
+		},
+		{
+			name:  "handler panic",
+			input: `{"hook_event_name": "Stop", "session_id": "test", "stop_hook_active": false}`,
+			want:  Result{ExitCode: 0, Stderr: "panic: stop failed\n"},
+		},
+		{
+			name:  "invalid input",
+			input: `{not json`,
+			want:  Result{ExitCode: 2, Stderr: "failed to decode stdin: invalid character 'n' looking for beginning of object key string\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Forward(context.Background(), socketPath, []byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Forward() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		mu.Lock()
+		calls = 0
+		mu.Unlock()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				input := `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`
+				if got, err := Forward(context.Background(), socketPath, []byte(input)); err != nil || got.ExitCode != 0 {
+					t.Errorf("Forward() = %+v, %v", got, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if calls != 20 {
+			t.Errorf("calls = %d, want 20", calls)
+		}
+	})
+
+	t.Run("second daemon on the same socket", func(t *testing.T) {
+		err := (&Runner{}).ListenAndServe(context.Background(), socketPath)
+		if err == nil || !strings.Contains(err.Error(), "already listening") {
+			t.Errorf("ListenAndServe() = %v", err)
+		}
+	})
+}
+
+func TestForwardWithoutDaemon(t *testing.T) {
+	_, err := Forward(context.Background(), filepath.Join(t.TempDir(), "missing.sock"), []byte(`{}`))
+	if !errors.Is(err, ErrDaemonUnavailable) {
+		t.Errorf("Forward() error = %v, want ErrDaemonUnavailable", err)
+	}
+}
+
+func TestForwardOrRun(t *testing.T) {
+	runner := &Runner{
+		UserPromptSubmit: func(ctx context.Context, event *UserPromptSubmitEvent) UserPromptSubmitResponseInterface {
+			return AddPromptContext("handled")
+		},
+	}
+	input := `{"hook_event_name": "UserPromptSubmit", "session_id": "test", "prompt": "hi"}`
+	want := "{\n  \"hookSpecificOutput\": {\n    \"hookEventName\": \"UserPromptSubmit\",\n    \"additionalContext\": \"handled\"\n  }\n}\n"
+
+	run := func(t *testing.T, runner *Runner, socketPath string) (string, int) {
+		t.Helper()
+
+		oldStdin, oldStdout := os.Stdin, os.Stdout
+		defer func() { os.Stdin, os.Stdout = oldStdin, oldStdout }()
+
+		stdinR, stdinW, _ := os.Pipe()
+		stdoutR, stdoutW, _ := os.Pipe()
+		os.Stdin, os.Stdout = stdinR, stdoutW
+		stdinW.Write([]byte(input))
+		stdinW.Close()
+
+		exitCode := -1
+		runner.ExitFn = func(code int) {
+			exitCode = code
+		}
+		runner.ForwardOrRun(context.Background(), socketPath)
+
+		stdoutW.Close()
+		output, _ := io.ReadAll(stdoutR)
+		return string(output), exitCode
+	}
+
+	t.Run("forwards to the daemon", func(t *testing.T) {
+		socketPath := startDaemon(t, runner)
+
+		// The client Runner has no handlers, so the output must come from the daemon
+		output, exitCode := run(t, &Runner{}, socketPath)
+		if exitCode != 0 || output != want {
+			t.Errorf("exit code = %d, output = %q, want %q", exitCode, output, want)
+		}
+	})
+
+	t.Run("runs in-process without a daemon", func(t *testing.T) {
+		output, exitCode := run(t, runner, filepath.Join(t.TempDir(), "missing.sock"))
+		if exitCode != 0 || output != want {
+			t.Errorf("exit code = %d, output = %q, want %q", exitCode, output, want)
+		}
+	})
+}
+
+func TestListenAndServeSocketPath(t *testing.T) {
+	newDir := func(t *testing.T) string {
+		t.Helper()
+		dir, err := os.MkdirTemp("", "cchooks")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		return dir
+	}
+
+	t.Run("does not delete a regular file", func(t *testing.T) {
+		socketPath := filepath.Join(newDir(t), "hook.sock")
+		if err := os.WriteFile(socketPath, []byte("important"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		err := (&Runner{}).ListenAndServe(context.Background(), socketPath)
+		if err == nil || !strings.Contains(err.Error(), "not a socket") {
+			t.Errorf("ListenAndServe() = %v, want a not a socket error", err)
+		}
+		if data, err := os.ReadFile(socketPath); err != nil || string(data) != "important" {
+			t.Errorf("file was modified: %q, %v", data, err)
+		}
+	})
+
+	t.Run("refuses a shared directory", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("permission bits are not enforced on Windows")
+		}
+		dir := newDir(t)
+		if err := os.Chmod(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		err := (&Runner{}).ListenAndServe(context.Background(), filepath.Join(dir, "hook.sock"))
+		if err == nil || !strings.Contains(err.Error(), "mode 0700") {
+			t.Errorf("ListenAndServe() = %v, want a mode 0700 error", err)
+		}
+	})
+
+	t.Run("creates a private directory and replaces a stale socket", func(t *testing.T) {
+		dir := filepath.Join(newDir(t), "run")
+		if err := os.Mkdir(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		socketPath := filepath.Join(dir, "hook.sock")
+
+		// A socket left behind by a daemon that exited without cleaning up
+		ln, err := net.Listen("unix", socketPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ln.(*net.UnixListener).SetUnlinkOnClose(false)
+		ln.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- (&Runner{}).ListenAndServe(ctx, socketPath)
+		}()
+		defer func() {
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("ListenAndServe() = %v", err)
+			}
+		}()
+
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+			if conn, err := net.Dial("unix", socketPath); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		t.Error("daemon did not replace the stale socket")
+	})
+
+	t.Run("creates a missing directory", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("permission bits are not enforced on Windows")
+		}
+		dir := filepath.Join(newDir(t), "missing")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := (&Runner{}).ListenAndServe(ctx, filepath.Join(dir, "hook.sock")); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o700 {
+			t.Errorf("directory mode = %v, want 0700", perm)
+		}
+	})
+}
+
+func TestForwardPeerCheck(t *testing.T) {
+	socketPath := startDaemon(t, &Runner{})
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	uid, ok, err := peerUID(conn.(*net.UnixConn), socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok && uid != os.Getuid() {
+		t.Errorf("peerUID() = %d, want %d", uid, os.Getuid())
+	}
+	if runtime.GOOS == "linux" && !ok {
+		t.Error("expected peer credentials on Linux")
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got, want := SocketPath("policy"), filepath.Join("/run/user/1000", "cchooks", "policy.sock"); got != want {
+		t.Errorf("SocketPath() = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	want := filepath.Join(os.TempDir(), fmt.Sprintf("cchooks-%d", os.Getuid()), "policy.sock")
+	if got := SocketPath("policy"); got != want {
+		t.Errorf("SocketPath() = %q, want %q", got, want)
+	}
+}
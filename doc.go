@@ -31,12 +31,117 @@ Create a hook by defining handlers for the events you want to process:
 
 # Event Types
 
-The SDK supports four event types that correspond to Claude Code's hook system:
+The SDK supports the following event types that correspond to Claude Code's hook system:
 
   - PreToolUse: Called before a tool is executed
   - PostToolUse: Called after a tool is executed
   - Notification: Called for Claude notifications
   - Stop: Called when Claude is stopping
+  - SubagentStop: Called when a Task sub-agent finishes
+  - PreCompact: Called before the conversation is compacted
+  - UserPromptSubmit: Called when the user submits a prompt, before Claude processes it
+  - SessionStart: Called when a session starts or resumes
+  - SessionEnd: Called when a session ends
+
+# Tool Routes
+
+Handlers can be registered for specific tools using Claude Code's matcher syntax.
+The first matching route handles the event; the PreToolUse and PostToolUse fields
+are used when no route matches:
+
+	runner.OnPreToolUse("Edit|Write|MultiEdit", checkPaths)
+	runner.OnPostToolUse("mcp__github__.*", auditGitHub)
+
+HandlePre and HandlePost register routes whose handlers receive typed tool data.
+Decode failures are reported to the Error handler as a *DecodeError:
+
+	cchooks.HandlePre(runner, "Bash", func(ctx context.Context, event *cchooks.PreToolUseEvent, bash *cchooks.BashInput) cchooks.PreToolUseResponseInterface {
+	    return cchooks.Approve()
+	})
+
+# Middleware
+
+Middleware wraps the dispatch of every event and can inspect the invocation and
+response or short-circuit with its own response:
+
+	runner.Use(func(next cchooks.HandlerFunc) cchooks.HandlerFunc {
+	    return func(ctx context.Context, inv *cchooks.Invocation) interface{} {
+	        log.Printf("handling %s", inv.EventName)
+	        return next(ctx, inv)
+	    }
+	})
+
+# Handler Timeouts
+
+HandlerTimeout gives every handler a deadline. When it passes, the Error handler
+receives a *TimeoutError and TimeoutFallback decides the response; by default a
+PreToolUse event is denied and other events get no output:
+
+	runner := &cchooks.Runner{
+	    HandlerTimeout: 5 * time.Second,
+	    StdinTimeout:   2 * time.Second,
+	}
+
+# Composing Policies
+
+Independent handlers can be combined with ComposePreToolUse, ComposePostToolUse and
+ComposeStop. Their responses are merged deterministically: deny wins over ask, ask
+wins over allow, reasons are concatenated and additional context is joined:
+
+	runner := &cchooks.Runner{
+	    PreToolUse: cchooks.ComposePreToolUse(securityPolicy, platformPolicy),
+	}
+
+# Aggregating Hooks
+
+An Aggregator runs several hook executables with the same input, in parallel and with
+per-hook timeouts, and merges their exit codes and outputs into a single response.
+The cchooks-aggregate command in cmd/cchooks-aggregate wraps it for use in settings files.
+
+# Unknown Fields
+
+Every event keeps the JSON it was decoded from. Fields the SDK does not bind yet are
+available from Extra, and any value can be looked up by path with Field:
+
+	if id, ok := event.Field("tool_use_id"); ok {
+	    log.Printf("tool use %v", id)
+	}
+
+# Embedding
+
+Execute handles one input and returns a Result with the output and exit code instead of
+writing to the process's streams and exiting, so hooks can be embedded in other programs
+and tested in parallel. Run uses the Runner's Stdin, Stdout and Stderr, which default to
+the standard streams.
+
+# Daemon Mode
+
+ListenAndServe hosts a Runner's handlers in a long-lived process on a Unix domain socket,
+so expensive setup is done once. ForwardOrRun, or the cchooks-shim command in
+cmd/cchooks-shim, forwards each invocation to the daemon and falls back to handling it
+without the daemon if it is not running. SocketPath places the socket in a directory
+private to the current user, and Forward never sends input to another user's daemon.
+
+# Logging
+
+Runner.Logger receives structured logs of every event, handler choice, decision and
+duration, and of errors the SDK recovers from, such as malformed transcript lines. If it
+is not set, logs are written to the file named by the CCHOOKS_LOG_FILE environment
+variable, since stdout and stderr are reserved for the hook protocol. Without it,
+warnings and errors still go to DefaultLogFile in the user's cache directory.
+
+# Transcripts
+
+Stop, SubagentStop and PreCompact events read the session transcript only when the
+handler asks for it: Transcript loads and caches the whole file, and LastEntries reads
+just the final entries by scanning backward from the end. OpenTranscript streams a
+transcript instead: Entries iterates over it, reporting malformed lines as
+*TranscriptLineError, and Offset records where to resume on a later invocation.
+
+Message content decodes into typed MessageBlocks (*TextBlock, *ToolUseBlock,
+*ToolResultBlock and so on). TranscriptEntry.Text, ToolUses and ToolResults cover the
+common questions without a type switch, and Transcript.ToolCalls pairs each tool call
+with its result to give a timeline of calls with typed inputs, errors and durations.
 
 # Tool Input Parsing
 
@@ -56,14 +161,29 @@ Events provide typed parsing methods for all Claude Code tools:
 The SDK provides helper functions for common responses:
 
 	// PreToolUse responses
-	cchooks.Approve()           // Allow the tool
-	cchooks.Block(reason)       // Block with reason
+	cchooks.AllowTool(reason)   // Allow the tool, bypassing the permission prompt
+	cchooks.DenyTool(reason)    // Deny the tool; the reason is shown to Claude
+	cchooks.AskUser(reason)     // Ask the user to confirm the tool use
+	cchooks.UpdateInput(bash, reason) // Ask the user to confirm a modified tool input
+	cchooks.WithUpdatedInput(cchooks.AllowTool(reason), bash) // Allow a modified input without a prompt
+	cchooks.Approve()           // Legacy "approve" decision
+	cchooks.Block(reason)       // Legacy "block" decision
 	cchooks.StopClaude(reason)  // Stop Claude
 
 	// PostToolUse responses
 	cchooks.Allow()             // Continue (empty response)
 	cchooks.PostBlock(reason)   // Block after execution
 
+	// UserPromptSubmit responses
+	cchooks.AllowPrompt()           // Let the prompt through
+	cchooks.BlockPrompt(reason)     // Reject the prompt
+	cchooks.AddPromptContext(text)  // Inject additional context
+
+	// Common output fields, available on every response
+	cchooks.WithSystemMessage(cchooks.AskUser(reason), "Shown to the user")
+	cchooks.WithSuppressOutput(cchooks.Allow())
+	cchooks.WithAdditionalContext(cchooks.Allow(), "Context for Claude")
+
 # Testing
 
 The SDK includes testing utilities for validating hook behavior:
@@ -102,6 +222,20 @@ The Raw handler:
   - Returns nil to continue with normal event processing
   - Useful for custom protocols, logging, or preprocessing
 
+# Unknown Events
+
+Events the SDK does not recognise pass through with exit code 0, so hooks keep working
+when Claude Code adds new event types. An Unknown handler receives them as a *GenericEvent,
+and UnknownEvents can be set to UnknownEventError to restore the previous behaviour of
+reporting them as errors:
+
+	runner := &cchooks.Runner{
+	    Unknown: func(ctx context.Context, event *cchooks.GenericEvent) *cchooks.RawResponse {
+	        log.Printf("unhandled %s event", event.HookEventName)
+	        return nil
+	    },
+	}
+
 # Error Handling
 
 Hooks communicate with Claude Code through exit codes:
@@ -109,6 +243,9 @@ Hooks communicate with Claude Code through exit codes:
   - Exit code 2: Error sent to Claude (default for non-Stop events when Error handler returns nil)
   - Other codes: Error shown to user
 
+Block decisions are written as JSON by default. Set Runner.ExitCodeBlocks, or wrap a
+response with WithExitCodeBlock, to emit them as exit code 2 with the reason on stderr.
+
 You can optionally handle SDK errors by providing an Error handler:
 
 	runner := &cchooks.Runner{
@@ -140,6 +277,6 @@ The Error handler is called whenever an error occurs inside the SDK, including:
   - Panics that occur during processing
 
 If the Error handler returns a non-nil RawResponse, that response is used instead of the default error handling.
-If it returns nil, the SDK will output the error to stderr and exit with code 2 (or code 0 for Stop events to avoid blocking Claude from stopping).
+If it returns nil, the SDK will output the error to stderr and exit with code 2 (or code 0 for Stop and SubagentStop events to avoid blocking Claude from stopping).
 */
 package cchooks
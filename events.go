@@ -2,35 +2,233 @@ package cchooks
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/brads3290/cchooks/internal/tools"
 )
 
+// HookInput is the envelope Claude Code sends with every hook event.
+// It is embedded in every event type, so its fields are available directly on each event.
+type HookInput struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	CWD            string `json:"cwd"`
+	HookEventName  string `json:"hook_event_name"`
+	PermissionMode string `json:"permission_mode,omitempty"`
+}
+
+// Constants for permission modes
+const (
+	PermissionModeDefault           = "default"
+	PermissionModePlan              = "plan"
+	PermissionModeAcceptEdits       = "acceptEdits"
+	PermissionModeBypassPermissions = "bypassPermissions"
+)
+
+// ResolvePath resolves path against the working directory of the session.
+// Absolute paths, and any path when CWD is unknown, are returned cleaned but otherwise unchanged.
+func (h *HookInput) ResolvePath(path string) string {
+	if filepath.IsAbs(path) || h.CWD == "" {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(h.CWD, path)
+}
+
 // Event types - data containers for each hook event
 type PreToolUseEvent struct {
-	SessionID string          `json:"session_id"`
+	HookInput
 	ToolName  string          `json:"tool_name"`
 	ToolInput json.RawMessage `json:"tool_input"`
+
+	payload
 }
 
 type PostToolUseEvent struct {
-	SessionID    string          `json:"session_id"`
+	HookInput
 	ToolName     string          `json:"tool_name"`
 	ToolInput    json.RawMessage `json:"tool_input"`
 	ToolResponse json.RawMessage `json:"tool_response"`
+
+	payload
 }
 
 type NotificationEvent struct {
-	SessionID string `json:"session_id"`
-	Message   string `json:"notification_message"`
+	HookInput
+	Message string `json:"notification_message"`
+
+	payload
 }
 
 type StopEvent struct {
-	SessionID      string            `json:"session_id"`
-	StopHookActive bool              `json:"stop_hook_active"`
-	TranscriptPath string            `json:"transcript_path"`
-	Transcript     []TranscriptEntry `json:"transcript"`
+	HookInput
+	StopHookActive bool `json:"stop_hook_active"`
+
+	transcript *transcriptLoader
+	payload
+}
+
+type SubagentStopEvent struct {
+	HookInput
+	StopHookActive bool `json:"stop_hook_active"`
+
+	transcript *transcriptLoader
+	payload
+}
+
+type PreCompactEvent struct {
+	HookInput
+	Trigger            string `json:"trigger"`
+	CustomInstructions string `json:"custom_instructions"`
+
+	transcript *transcriptLoader
+	payload
+}
+
+// Constants for PreCompact triggers
+const (
+	PreCompactTriggerManual = "manual"
+	PreCompactTriggerAuto   = "auto"
+)
+
+type UserPromptSubmitEvent struct {
+	HookInput
+	Prompt string `json:"prompt"`
+
+	payload
+}
+
+type SessionStartEvent struct {
+	HookInput
+	Source string `json:"source"`
+
+	payload
+}
+
+// Constants for SessionStart sources
+const (
+	SessionStartSourceStartup = "startup"
+	SessionStartSourceResume  = "resume"
+	SessionStartSourceClear   = "clear"
+	SessionStartSourceCompact = "compact"
+)
+
+type SessionEndEvent struct {
+	HookInput
+	Reason string `json:"reason"`
+
+	payload
+}
+
+// Constants for SessionEnd reasons
+const (
+	SessionEndReasonClear           = "clear"
+	SessionEndReasonLogout          = "logout"
+	SessionEndReasonPromptInputExit = "prompt_input_exit"
+	SessionEndReasonOther           = "other"
+)
+
+// Transcript accessors
+//
+// Transcripts are read from TranscriptPath on first use and cached, so handlers that never
+// look at the transcript don't pay for reading it. Read errors are logged and give an empty
+// (never nil) transcript.
+
+// Transcript returns every entry of the session transcript.
+func (e *StopEvent) Transcript() Transcript {
+	return e.transcript.all()
+}
+
+// LastEntries returns the final n entries of the session transcript.
+// Unless Transcript has already been called, only the end of the file is read.
+func (e *StopEvent) LastEntries(n int) Transcript {
+	return e.transcript.last(n)
+}
+
+// Transcript returns every entry of the session transcript, including sub-agent entries.
+func (e *SubagentStopEvent) Transcript() Transcript {
+	return e.transcript.all()
+}
+
+// LastEntries returns the final n entries of the session transcript.
+// Unless Transcript has already been called, only the end of the file is read.
+func (e *SubagentStopEvent) LastEntries(n int) Transcript {
+	return e.transcript.last(n)
+}
+
+// Transcript returns every entry of the transcript that is about to be compacted.
+func (e *PreCompactEvent) Transcript() Transcript {
+	return e.transcript.all()
+}
+
+// LastEntries returns the final n entries of the transcript that is about to be compacted.
+// Unless Transcript has already been called, only the end of the file is read.
+func (e *PreCompactEvent) LastEntries(n int) Transcript {
+	return e.transcript.last(n)
+}
+
+// Sub-agent transcript helpers for SubagentStopEvent
+
+// SidechainEntries returns every transcript entry that belongs to a sub-agent (isSidechain is true).
+func (e *SubagentStopEvent) SidechainEntries() Transcript {
+	return sidechainEntries(e.Transcript())
+}
+
+// SubagentEntries returns the entries of the sub-agent that just finished, in conversation order.
+// The sub-agent is identified as the sidechain thread containing the most recent sidechain entry.
+func (e *SubagentStopEvent) SubagentEntries() Transcript {
+	return lastSidechainThread(e.Transcript())
+}
+
+// LastSubagentMessage returns the final assistant entry produced by the sub-agent that just finished.
+// Returns nil if the sub-agent has no assistant entries in the transcript.
+func (e *SubagentStopEvent) LastSubagentMessage() *TranscriptEntry {
+	thread := lastSidechainThread(e.Transcript())
+	for i := len(thread) - 1; i >= 0; i-- {
+		if thread[i].IsAssistantMessage() {
+			return &thread[i]
+		}
+	}
+	return nil
+}
+
+// Compaction helpers for PreCompactEvent
+
+// IsManual returns true if compaction was requested by the user via /compact.
+func (e *PreCompactEvent) IsManual() bool {
+	return e.Trigger == PreCompactTriggerManual
+}
+
+// IsAuto returns true if compaction was triggered automatically because the context window is full.
+func (e *PreCompactEvent) IsAuto() bool {
+	return e.Trigger == PreCompactTriggerAuto
+}
+
+// SnapshotTranscript copies the transcript file byte-for-byte to dst before it is summarised away.
+func (e *PreCompactEvent) SnapshotTranscript(dst string) error {
+	if e.TranscriptPath == "" {
+		return fmt.Errorf("no transcript path available")
+	}
+
+	src, err := os.Open(e.TranscriptPath)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript file: %w", err)
+	}
+	defer src.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy transcript: %w", err)
+	}
+	return out.Close()
 }
 
 // Interface implementations for tools package
@@ -12,64 +12,9 @@ import (
 
 func main() {
 	runner := &cchooks.Runner{
+		// Tools without a route are approved
 		PreToolUse: func(ctx context.Context, event *cchooks.PreToolUseEvent) cchooks.PreToolUseResponseInterface {
-			switch event.ToolName {
-			case "Bash":
-				bash, err := event.AsBash()
-				if err != nil {
-					return cchooks.Error(err)
-				}
-
-				// Block dangerous commands
-				dangerous := []string{"rm -rf", "sudo rm", "dd if=", ":(){ :|: & };:"}
-				for _, pattern := range dangerous {
-					if strings.Contains(bash.Command, pattern) {
-						return cchooks.Block(fmt.Sprintf("Dangerous command pattern detected: %s", pattern))
-					}
-				}
-
-				// Warn about sudo usage
-				if strings.HasPrefix(bash.Command, "sudo") {
-					log.Printf("WARNING: sudo command detected: %s", bash.Command)
-				}
-
-				return cchooks.Approve()
-
-			case "Edit", "Write":
-				// Check file paths
-				var filePath string
-				if event.ToolName == "Edit" {
-					edit, err := event.AsEdit()
-					if err != nil {
-						return cchooks.Error(err)
-					}
-					filePath = edit.FilePath
-				} else {
-					write, err := event.AsWrite()
-					if err != nil {
-						return cchooks.Error(err)
-					}
-					filePath = write.FilePath
-				}
-
-				// Block editing production files
-				if strings.Contains(filePath, "/production/") {
-					return cchooks.Block("Cannot edit production files")
-				}
-
-				// Block editing system files
-				systemPaths := []string{"/etc/", "/usr/", "/bin/", "/sbin/", "/boot/"}
-				for _, systemPath := range systemPaths {
-					if strings.HasPrefix(filePath, systemPath) {
-						return cchooks.Block(fmt.Sprintf("Cannot edit system files in %s", systemPath))
-					}
-				}
-
-				return cchooks.Approve()
-
-			default:
-				return cchooks.Approve()
-			}
+			return cchooks.Approve()
 		},
 
 		PostToolUse: func(ctx context.Context, event *cchooks.PostToolUseEvent) cchooks.PostToolUseResponseInterface {
@@ -125,5 +70,60 @@ func main() {
 		},
 	}
 
+	runner.OnPreToolUse("Bash", func(ctx context.Context, event *cchooks.PreToolUseEvent) cchooks.PreToolUseResponseInterface {
+		bash, err := event.AsBash()
+		if err != nil {
+			return cchooks.Error(err)
+		}
+
+		// Block dangerous commands
+		dangerous := []string{"rm -rf", "sudo rm", "dd if=", ":(){ :|: & };:"}
+		for _, pattern := range dangerous {
+			if strings.Contains(bash.Command, pattern) {
+				return cchooks.Block(fmt.Sprintf("Dangerous command pattern detected: %s", pattern))
+			}
+		}
+
+		// Warn about sudo usage
+		if strings.HasPrefix(bash.Command, "sudo") {
+			log.Printf("WARNING: sudo command detected: %s", bash.Command)
+		}
+
+		return cchooks.Approve()
+	})
+
+	runner.OnPreToolUse("Edit|Write", func(ctx context.Context, event *cchooks.PreToolUseEvent) cchooks.PreToolUseResponseInterface {
+		// Check file paths
+		var filePath string
+		if event.ToolName == "Edit" {
+			edit, err := event.AsEdit()
+			if err != nil {
+				return cchooks.Error(err)
+			}
+			filePath = edit.FilePath
+		} else {
+			write, err := event.AsWrite()
+			if err != nil {
+				return cchooks.Error(err)
+			}
+			filePath = write.FilePath
+		}
+
+		// Block editing production files
+		if strings.Contains(filePath, "/production/") {
+			return cchooks.Block("Cannot edit production files")
+		}
+
+		// Block editing system files
+		systemPaths := []string{"/etc/", "/usr/", "/bin/", "/sbin/", "/boot/"}
+		for _, systemPath := range systemPaths {
+			if strings.HasPrefix(filePath, systemPath) {
+				return cchooks.Block(fmt.Sprintf("Cannot edit system files in %s", systemPath))
+			}
+		}
+
+		return cchooks.Approve()
+	})
+
 	runner.Run()
-}
\ No newline at end of file
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"log"
-	"strings"
 
 	cchooks "github.com/brads3290/cchooks"
 )
@@ -16,47 +15,41 @@ func main() {
 			log.Printf("Stop event received. Session: %s, StopHookActive: %v\n",
 				event.SessionID, event.StopHookActive)
 
-			// Analyze transcript if available
-			if len(event.Transcript) > 0 {
-				log.Printf("Transcript contains %d entries\n", len(event.Transcript))
+			// Analyze transcript if available (it is read from disk on first access)
+			transcript := event.Transcript()
+			if len(transcript) > 0 {
+				log.Printf("Transcript contains %d entries\n", len(transcript))
 
 				// Count message types
 				userMessages := 0
 				assistantMessages := 0
-				toolUses := 0
 
-				for _, entry := range event.Transcript {
+				for _, entry := range transcript {
 					if entry.IsUserMessage() {
 						userMessages++
 					} else if entry.IsAssistantMessage() {
 						assistantMessages++
-
-						// Check for tool use
-						if msg, err := entry.GetAssistantMessage(); err == nil && msg != nil {
-							// Simple check - in real usage you'd parse the content array
-							contentStr := string(msg.Content)
-							if strings.Contains(contentStr, "tool_use") {
-								toolUses++
-							}
-						}
 					}
 				}
 
-				log.Printf("Summary: %d user messages, %d assistant messages, %d tool uses\n",
-					userMessages, assistantMessages, toolUses)
+				calls := transcript.ToolCalls()
+				log.Printf("Summary: %d user messages, %d assistant messages, %d tool calls\n",
+					userMessages, assistantMessages, len(calls))
+
+				// Report tool calls that failed
+				for _, call := range calls {
+					if call.IsError() {
+						log.Printf("Tool %s failed after %v: %s\n", call.Name, call.Duration(), call.Result.Text())
+					}
+				}
 
-				// Show last user message if available
-				for i := len(event.Transcript) - 1; i >= 0; i-- {
-					if event.Transcript[i].IsUserMessage() {
-						if msg, err := event.Transcript[i].GetUserMessage(); err == nil && msg != nil {
-							// Try to extract text content
-							contentStr := string(msg.Content)
-							if strings.HasPrefix(contentStr, "\"") && strings.HasSuffix(contentStr, "\"") {
-								contentStr = contentStr[1 : len(contentStr)-1]
-							}
-							log.Printf("Last user message: %s\n", contentStr)
+				// Show last user message if available (tool results are user entries without text)
+				for i := len(transcript) - 1; i >= 0; i-- {
+					if transcript[i].IsUserMessage() {
+						if text := transcript[i].Text(); text != "" {
+							log.Printf("Last user message: %s\n", text)
+							break
 						}
-						break
 					}
 				}
 			} else {
@@ -0,0 +1,163 @@
+package cchooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestExecute(t *testing.T) {
+	runner := &Runner{
+		PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+			if bash, _ := event.AsBash(); bash != nil && strings.HasPrefix(bash.Command, "sudo ") {
+				return DenyTool("no sudo")
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context, event *StopEvent) StopResponseInterface {
+			return Error(errors.New("stop check failed"))
+		},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  Result
+	}{
+		{
+			name:  "JSON response",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "sudo ls"}}`,
+			want: Result{Stdout: `{
+  "hookSpecificOutput": {
+    "hookEventName": "PreToolUse",
+    "permissionDecision": "deny",
+    "permissionDecisionReason": "no sudo"
+  }
+}
+`},
+		},
+		{
+			name:  "empty response",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`,
+			want:  Result{},
+		},
+		{
+			name:  "Stop error does not block",
+			input: `{"hook_event_name": "Stop", "session_id": "test", "stop_hook_active": true}`,
+			want:  Result{ExitCode: 0, Stderr: "stop check failed\n"},
+		},
+		{
+			name:  "undecodable event without a handler passes through",
+			input: `{"hook_event_name": "PostToolUse", "session_id": "test", "tool_name": 5}`,
+			want:  Result{},
+		},
+		{
+			name:  "undecodable event with a handler blocks",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": 5}`,
+			want:  Result{ExitCode: 2, Stderr: "failed to parse PreToolUseEvent: json: cannot unmarshal number into Go struct field PreToolUseEvent.tool_name of type string\n"},
+		},
+		{
+			name:  "invalid input",
+			input: `{"session_id": "test"}`,
+			want:  Result{ExitCode: 2, Stderr: "missing or invalid hook_event_name field\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute does not touch process globals, so cases can run concurrently
+			t.Parallel()
+			if got := runner.Execute(context.Background(), strings.NewReader(tt.input)); got != tt.want {
+				t.Errorf("Execute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("read error", func(t *testing.T) {
+		t.Parallel()
+		got := runner.Execute(context.Background(), iotest.ErrReader(errors.New("broken pipe")))
+		want := Result{ExitCode: 2, Stderr: "failed to read stdin: broken pipe\n"}
+		if got != want {
+			t.Errorf("Execute() = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestRunnerStreams(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := -1
+	runner := &Runner{
+		Stdin:          strings.NewReader(`{"hook_event_name": "UserPromptSubmit", "session_id": "test", "prompt": "deploy"}`),
+		Stdout:         &stdout,
+		Stderr:         &stderr,
+		ExitFn:         func(code int) { exitCode = code },
+		ExitCodeBlocks: true,
+		UserPromptSubmit: func(ctx context.Context, event *UserPromptSubmitEvent) UserPromptSubmitResponseInterface {
+			return BlockPrompt("deploys are frozen")
+		},
+	}
+
+	runner.Run()
+
+	if exitCode != 2 {
+		t.Errorf("exit code = %d, want 2", exitCode)
+	}
+	if stdout.String() != "" {
+		t.Errorf("stdout = %q, want empty", stdout.String())
+	}
+	if stderr.String() != "deploys are frozen\n" {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+func TestExecuteExitCodeBlocks(t *testing.T) {
+	runner := &Runner{
+		ExitCodeBlocks: true,
+		Stop: func(ctx context.Context, event *StopEvent) StopResponseInterface {
+			return BlockStop("run the tests first")
+		},
+		SubagentStop: func(ctx context.Context, event *SubagentStopEvent) SubagentStopResponseInterface {
+			return BlockSubagentStop("")
+		},
+		PreCompact: func(ctx context.Context, event *PreCompactEvent) PreCompactResponseInterface {
+			return StopFromPreCompact("snapshot failed")
+		},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  Result
+	}{
+		{
+			name:  "block decision exits 2",
+			input: `{"hook_event_name": "Stop", "session_id": "test", "stop_hook_active": false}`,
+			want:  Result{ExitCode: 2, Stderr: "run the tests first\n"},
+		},
+		{
+			name:  "empty reason gets a default",
+			input: `{"hook_event_name": "SubagentStop", "session_id": "test", "stop_hook_active": false}`,
+			want:  Result{ExitCode: 2, Stderr: "blocked by hook\n"},
+		},
+		{
+			name:  "responses without a block decision are written as JSON",
+			input: `{"hook_event_name": "PreCompact", "session_id": "test", "trigger": "auto"}`,
+			want: Result{Stdout: `{
+  "continue": false,
+  "stopReason": "snapshot failed"
+}
+`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runner.Execute(context.Background(), strings.NewReader(tt.input)); got != tt.want {
+				t.Errorf("Execute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package cchooks
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// payload is embedded in every event type and records the JSON the event was decoded from
+// It provides RawJSON, Extra and Field to every event.
+type payload struct {
+	raw json.RawMessage
+	// known is the set of JSON field names bound to members of the event struct
+	known map[string]struct{}
+}
+
+// RawJSON returns the original JSON payload the event was decoded from
+// It is nil for events that were not decoded from input, such as struct literals.
+func (p *payload) RawJSON() json.RawMessage {
+	return p.raw
+}
+
+// Extra returns the payload fields that are not bound to a member of the event struct
+// New protocol fields can be read from here before the SDK has typed support for them.
+// It returns an empty map if there are none or the event was not decoded from input.
+func (p *payload) Extra() map[string]json.RawMessage {
+	extra := map[string]json.RawMessage{}
+	if p.raw == nil {
+		return extra
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(p.raw, &fields); err != nil {
+		return extra
+	}
+	for name, value := range fields {
+		if _, ok := p.known[name]; !ok {
+			extra[name] = value
+		}
+	}
+	return extra
+}
+
+// Field looks up a value in the original payload by a dot-separated path
+// Path segments are object keys or array indexes, e.g. "tool_input.edits.0.old_string".
+// The value is decoded as by json.Unmarshal into an interface{}. The second result
+// is false if the path does not exist.
+func (p *payload) Field(path string) (interface{}, bool) {
+	if p.raw == nil {
+		return nil, false
+	}
+
+	current := p.raw
+	for _, segment := range strings.Split(path, ".") {
+		next, ok := lookupSegment(current, segment)
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(current, &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// lookupSegment returns the member of a JSON object or element of a JSON array named by segment
+func lookupSegment(data json.RawMessage, segment string) (json.RawMessage, bool) {
+	var object map[string]json.RawMessage
+	if json.Unmarshal(data, &object) == nil {
+		value, ok := object[segment]
+		return value, ok
+	}
+
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 {
+		return nil, false
+	}
+	var array []json.RawMessage
+	if json.Unmarshal(data, &array) != nil || index >= len(array) {
+		return nil, false
+	}
+	return array[index], true
+}
+
+func (p *payload) eventPayload() *payload {
+	return p
+}
+
+// payloadEvent is implemented by every event type through the embedded payload
+type payloadEvent interface {
+	eventPayload() *payload
+}
+
+// bindRaw records the payload an event was decoded from, for RawJSON, Extra and Field
+func bindRaw(event interface{}, rawJSON []byte) {
+	e, ok := event.(payloadEvent)
+	if !ok {
+		return
+	}
+	p := e.eventPayload()
+	p.raw = rawJSON
+	p.known = knownFields(reflect.TypeOf(event))
+}
+
+// knownFieldsCache maps event types to the JSON field names bound to their struct members
+var knownFieldsCache sync.Map
+
+// knownFields returns the JSON field names bound to members of the struct t points to
+func knownFields(t reflect.Type) map[string]struct{} {
+	if cached, ok := knownFieldsCache.Load(t); ok {
+		return cached.(map[string]struct{})
+	}
+
+	fields := map[string]struct{}{}
+	collectFields(t.Elem(), fields)
+	knownFieldsCache.Store(t, fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, fields map[string]struct{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectFields(field.Type, fields)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fields[name] = struct{}{}
+	}
+}
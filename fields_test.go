@@ -0,0 +1,95 @@
+package cchooks
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestEventFields(t *testing.T) {
+	rawJSON := []byte(`{
+		"hook_event_name": "PreToolUse",
+		"session_id": "test",
+		"cwd": "/project",
+		"tool_name": "MultiEdit",
+		"tool_input": {"file_path": "a.go", "edits": [{"old_string": "foo", "new_string": "bar"}]},
+		"tool_use_id": "toolu_01",
+		"agent": {"name": "reviewer", "depth": 2}
+	}`)
+
+	decoded, err := decodeEvent("PreToolUse", rawJSON, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := decoded.(*PreToolUseEvent)
+
+	t.Run("RawJSON", func(t *testing.T) {
+		if string(event.RawJSON()) != string(rawJSON) {
+			t.Errorf("RawJSON() = %s", event.RawJSON())
+		}
+	})
+
+	t.Run("Extra", func(t *testing.T) {
+		extra := event.Extra()
+		if len(extra) != 2 {
+			t.Errorf("Extra() = %v, want tool_use_id and agent", extra)
+		}
+		if string(extra["tool_use_id"]) != `"toolu_01"` {
+			t.Errorf("tool_use_id = %s", extra["tool_use_id"])
+		}
+		var agent struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(extra["agent"], &agent); err != nil || agent.Name != "reviewer" {
+			t.Errorf("agent = %s", extra["agent"])
+		}
+	})
+
+	t.Run("Field", func(t *testing.T) {
+		tests := []struct {
+			path   string
+			want   interface{}
+			wantOK bool
+		}{
+			{path: "tool_use_id", want: "toolu_01", wantOK: true},
+			{path: "agent.depth", want: float64(2), wantOK: true},
+			{path: "tool_input.edits.0.old_string", want: "foo", wantOK: true},
+			{path: "session_id", want: "test", wantOK: true},
+			{path: "tool_input.edits.1.old_string", wantOK: false},
+			{path: "tool_input.edits.x", wantOK: false},
+			{path: "agent.name.first", wantOK: false},
+			{path: "missing", wantOK: false},
+		}
+		for _, tt := range tests {
+			got, ok := event.Field(tt.path)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Field(%q) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		}
+	})
+
+	t.Run("events not decoded from input", func(t *testing.T) {
+		event := &StopEvent{}
+		if event.RawJSON() != nil || len(event.Extra()) != 0 {
+			t.Errorf("RawJSON() = %s, Extra() = %v", event.RawJSON(), event.Extra())
+		}
+		if _, ok := event.Field("session_id"); ok {
+			t.Error("expected Field to report a missing value")
+		}
+	})
+
+	t.Run("available to handlers", func(t *testing.T) {
+		var extra map[string]json.RawMessage
+		runner := &Runner{
+			Notification: func(ctx context.Context, event *NotificationEvent) NotificationResponseInterface {
+				extra = event.Extra()
+				return nil
+			},
+		}
+		NewTestRunner(runner).TestNotification("hello")
+		if len(extra) != 0 {
+			t.Errorf("Extra() = %v, want no extra fields", extra)
+		}
+	})
+}
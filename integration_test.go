@@ -22,6 +22,7 @@ package main
 
 import (
 	"context"
+	"os"
 	"strings"
 	cchooks "github.com/brads3290/cchooks"
 )
@@ -35,8 +36,18 @@ func main() {
 					return cchooks.Block("dangerous command")
 				}
 			}
+			if event.ToolName == "Write" {
+				write, _ := event.AsWrite()
+				if strings.HasPrefix(write.FilePath, "/etc/") {
+					return cchooks.WithExitCodeBlock(cchooks.DenyTool("protected file"))
+				}
+			}
 			return cchooks.Approve()
 		},
+		Stop: func(ctx context.Context, event *cchooks.StopEvent) cchooks.StopResponseInterface {
+			return cchooks.BlockStop("run the tests first")
+		},
+		ExitCodeBlocks: os.Getenv("HOOK_EXIT_CODE_BLOCKS") == "1",
 	}
 	
 	runner.Run()
@@ -92,7 +103,9 @@ replace github.com/brads3290/cchooks => %s
 	tests := []struct {
 		name       string
 		input      map[string]interface{}
+		env        []string
 		wantOutput string
+		wantStderr string
 		wantExit   int
 	}{
 		{
@@ -128,6 +141,62 @@ replace github.com/brads3290/cchooks => %s
 `,
 			wantExit: 0,
 		},
+		{
+			name: "block with exit code 2",
+			input: map[string]interface{}{
+				"hook_event_name": "PreToolUse",
+				"session_id":      "test-789",
+				"tool_name":       "Bash",
+				"tool_input": map[string]interface{}{
+					"command": "rm -rf /",
+				},
+			},
+			env:        []string{"HOOK_EXIT_CODE_BLOCKS=1"},
+			wantStderr: "dangerous command\n",
+			wantExit:   2,
+		},
+		{
+			name: "approve with exit code blocks enabled",
+			input: map[string]interface{}{
+				"hook_event_name": "PreToolUse",
+				"session_id":      "test-789",
+				"tool_name":       "Bash",
+				"tool_input": map[string]interface{}{
+					"command": "ls",
+				},
+			},
+			env: []string{"HOOK_EXIT_CODE_BLOCKS=1"},
+			wantOutput: `{
+  "decision": "approve"
+}
+`,
+			wantExit: 0,
+		},
+		{
+			name: "stop block with exit code 2",
+			input: map[string]interface{}{
+				"hook_event_name":  "Stop",
+				"session_id":       "test-789",
+				"stop_hook_active": false,
+			},
+			env:        []string{"HOOK_EXIT_CODE_BLOCKS=1"},
+			wantStderr: "run the tests first\n",
+			wantExit:   2,
+		},
+		{
+			name: "per-response exit code block",
+			input: map[string]interface{}{
+				"hook_event_name": "PreToolUse",
+				"session_id":      "test-789",
+				"tool_name":       "Write",
+				"tool_input": map[string]interface{}{
+					"file_path": "/etc/hosts",
+					"content":   "",
+				},
+			},
+			wantStderr: "protected file\n",
+			wantExit:   2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -141,6 +210,7 @@ replace github.com/brads3290/cchooks => %s
 			// Run the hook
 			cmd := exec.Command(hookBinary)
 			cmd.Stdin = bytes.NewReader(inputJSON)
+			cmd.Env = append(os.Environ(), tt.env...)
 
 			var stdout, stderr bytes.Buffer
 			cmd.Stdout = &stdout
@@ -163,6 +233,9 @@ replace github.com/brads3290/cchooks => %s
 			if stdout.String() != tt.wantOutput {
 				t.Errorf("output = %q, want %q", stdout.String(), tt.wantOutput)
 			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
 		})
 	}
 }
@@ -310,7 +310,7 @@ func TestPreToolUseEventParsing(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			event := &cchooks.PreToolUseEvent{
-				SessionID: "test",
+				HookInput: cchooks.HookInput{SessionID: "test"},
 				ToolName:  "TestTool",
 				ToolInput: json.RawMessage(tt.toolInput),
 			}
@@ -328,7 +328,7 @@ func TestPreToolUseEventParsing(t *testing.T) {
 func TestPostToolUseEventParsing(t *testing.T) {
 	t.Run("input parsing", func(t *testing.T) {
 		event := &cchooks.PostToolUseEvent{
-			SessionID:    "test",
+			HookInput:    cchooks.HookInput{SessionID: "test"},
 			ToolName:     "Bash",
 			ToolInput:    json.RawMessage(`{"command": "echo test"}`),
 			ToolResponse: json.RawMessage(`{"output": "test", "exit_code": 0}`),
@@ -458,7 +458,7 @@ func TestPostToolUseEventParsing(t *testing.T) {
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
 				event := &cchooks.PostToolUseEvent{
-					SessionID:    "test",
+					HookInput:    cchooks.HookInput{SessionID: "test"},
 					ToolName:     "TestTool",
 					ToolInput:    json.RawMessage(`{}`),
 					ToolResponse: json.RawMessage(tt.toolResponse),
@@ -476,7 +476,7 @@ func TestPostToolUseEventParsing(t *testing.T) {
 
 	t.Run("invalid JSON", func(t *testing.T) {
 		event := &cchooks.PreToolUseEvent{
-			SessionID: "test",
+			HookInput: cchooks.HookInput{SessionID: "test"},
 			ToolName:  "Bash",
 			ToolInput: json.RawMessage(`{"invalid json`),
 		}
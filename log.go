@@ -0,0 +1,142 @@
+package cchooks
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// LogFileEnv is the environment variable naming the file a Runner logs to when Logger is not set
+// Stdout and stderr carry the hook protocol, so diagnostics are never written there.
+// Setting it to an empty string disables the default log file.
+const LogFileEnv = "CCHOOKS_LOG_FILE"
+
+// defaultLogger is the logger used by Runners without a Logger
+// It logs at debug level to the file named by LogFileEnv if it is set, and otherwise
+// records warnings and errors in the default log file.
+var defaultLogger = sync.OnceValue(func() *slog.Logger {
+	if path, ok := os.LookupEnv(LogFileEnv); ok {
+		return newFileLogger(path, slog.LevelDebug)
+	}
+	return newFileLogger(DefaultLogFile(), slog.LevelWarn)
+})
+
+// DefaultLogFile returns the file Runners without a Logger record warnings and errors in
+// when LogFileEnv is not set: cchooks/hooks.log in the user's cache directory. It returns
+// an empty string if the cache directory is unknown.
+func DefaultLogFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cchooks", "hooks.log")
+}
+
+// newFileLogger returns a logger appending JSON logs at the given level to the file at path
+// Logs are discarded if path is empty or the file cannot be opened.
+func newFileLogger(path string, level slog.Level) *slog.Logger {
+	if path == "" {
+		return slog.New(slog.DiscardHandler)
+	}
+	return slog.New(slog.NewJSONHandler(&lazyFile{path: path}, &slog.HandlerOptions{Level: level}))
+}
+
+// lazyFile opens a log file and its directory on the first write, so that hooks that
+// log nothing leave no file behind
+type lazyFile struct {
+	path string
+	once sync.Once
+	file *os.File
+	err  error
+}
+
+func (f *lazyFile) Write(p []byte) (int, error) {
+	f.once.Do(func() {
+		if f.err = os.MkdirAll(filepath.Dir(f.path), 0o700); f.err == nil {
+			f.file, f.err = os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		}
+	})
+	if f.err != nil {
+		return 0, f.err
+	}
+	return f.file.Write(p)
+}
+
+// logger returns the Runner's Logger, or the default logger if none is set
+func (r *Runner) logger() *slog.Logger {
+	if r.Logger != nil {
+		return r.Logger
+	}
+	return defaultLogger()
+}
+
+// handlerLabel returns name if the handler is set, or "none"
+func handlerLabel(name string, set bool) string {
+	if set {
+		return name
+	}
+	return "none"
+}
+
+// handlerName describes the handler dispatch calls for an invocation, or "none"
+// Routed and Once handlers are labelled by the same lookups dispatch uses.
+func (r *Runner) handlerName(inv *Invocation) string {
+	var label string
+	switch event := inv.Event.(type) {
+	case *PreToolUseEvent:
+		_, label = r.preToolUseHandler(event.ToolName)
+	case *PostToolUseEvent:
+		_, label = r.postToolUseHandler(event.ToolName)
+	case *NotificationEvent:
+		label = handlerLabel("Notification", r.Notification != nil)
+	case *StopEvent:
+		_, label = r.stopHandler(event.StopHookActive)
+	case *SubagentStopEvent:
+		_, label = r.subagentStopHandler(event.StopHookActive)
+	case *PreCompactEvent:
+		label = handlerLabel("PreCompact", r.PreCompact != nil)
+	case *UserPromptSubmitEvent:
+		label = handlerLabel("UserPromptSubmit", r.UserPromptSubmit != nil)
+	case *SessionStartEvent:
+		label = handlerLabel("SessionStart", r.SessionStart != nil)
+	case *SessionEndEvent:
+		label = handlerLabel("SessionEnd", r.SessionEnd != nil)
+	case *GenericEvent:
+		label = handlerLabel("Unknown", r.Unknown != nil)
+	default:
+		label = "none"
+	}
+	return label
+}
+
+// responseDecision summarises the decision a response makes, for logging
+// It is empty for responses without a decision.
+func responseDecision(response interface{}) string {
+	switch v := response.(type) {
+	case *PreToolUseResponse:
+		return v.PermissionDecision()
+	case *PostToolUseResponse:
+		return v.Decision
+	case *StopResponse:
+		return v.Decision
+	case *SubagentStopResponse:
+		return v.Decision
+	case *UserPromptSubmitResponse:
+		return v.Decision
+	case *RawResponse:
+		return "raw"
+	case *ErrorResponse:
+		return "error"
+	}
+	return ""
+}
+
+// logDispatch records the handler chosen for an invocation
+func (r *Runner) logDispatch(ctx context.Context, inv *Invocation) {
+	log := r.logger()
+	if log.Enabled(ctx, slog.LevelDebug) {
+		log.DebugContext(ctx, "handler chosen", "event", inv.EventName, "handler", r.handlerName(inv))
+	}
+}
@@ -0,0 +1,179 @@
+package cchooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestMain keeps Runners without a Logger from writing to the user's default log file
+func TestMain(m *testing.M) {
+	os.Setenv(LogFileEnv, "")
+	os.Exit(m.Run())
+}
+
+// logRecords decodes JSON log output into one map per record
+func logRecords(t *testing.T, data []byte) []map[string]interface{} {
+	t.Helper()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// findRecord returns the first record with the given message, or nil
+func findRecord(records []map[string]interface{}, msg string) map[string]interface{} {
+	for _, record := range records {
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	return nil
+}
+
+func TestRunnerLogging(t *testing.T) {
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	transcript := `{"type": "user", "uuid": "1"}` + "\n" + `{not json` + "\n"
+	if err := os.WriteFile(transcriptPath, []byte(transcript), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	runner := &Runner{
+		Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		StopOnce: func(ctx context.Context, event *StopEvent) StopResponseInterface {
+			event.Transcript()
+			return BlockStop("run the tests")
+		},
+	}
+	runner.OnPreToolUse("Bash", func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+		return Error(os.ErrPermission)
+	})
+
+	t.Run("handled event", func(t *testing.T) {
+		buf.Reset()
+		input := `{"hook_event_name": "Stop", "session_id": "test", "transcript_path": "` + transcriptPath + `", "stop_hook_active": false}`
+		runner.execute(context.Background(), []byte(input))
+		records := logRecords(t, buf.Bytes())
+
+		if record := findRecord(records, "event received"); record == nil || record["event"] != "Stop" {
+			t.Errorf("event received = %v", record)
+		}
+		if record := findRecord(records, "handler chosen"); record == nil || record["handler"] != "StopOnce" {
+			t.Errorf("handler chosen = %v", record)
+		}
+		if record := findRecord(records, "skipping malformed transcript line"); record == nil || record["line"] != float64(2) {
+			t.Errorf("skipping malformed transcript line = %v", record)
+		}
+		record := findRecord(records, "event handled")
+		if record == nil || record["decision"] != "block" || record["exit_code"] != float64(0) {
+			t.Errorf("event handled = %v", record)
+		}
+		if _, ok := record["duration"]; !ok {
+			t.Error("expected a duration")
+		}
+	})
+
+	t.Run("unterminated malformed last line", func(t *testing.T) {
+		truncatedPath := filepath.Join(t.TempDir(), "truncated.jsonl")
+		if err := os.WriteFile(truncatedPath, []byte(`{"type": "user", "uuid": "1"}`+"\n"+`{"type": "us`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		buf.Reset()
+		input := `{"hook_event_name": "Stop", "session_id": "test", "transcript_path": "` + truncatedPath + `", "stop_hook_active": false}`
+		runner.execute(context.Background(), []byte(input))
+
+		if record := findRecord(logRecords(t, buf.Bytes()), "skipping malformed transcript line"); record == nil || record["line"] != float64(2) {
+			t.Errorf("skipping malformed transcript line = %v", record)
+		}
+	})
+
+	t.Run("routed handler error", func(t *testing.T) {
+		buf.Reset()
+		input := `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`
+		runner.execute(context.Background(), []byte(input))
+		records := logRecords(t, buf.Bytes())
+
+		if record := findRecord(records, "handler chosen"); record == nil || record["handler"] != `OnPreToolUse("Bash")` {
+			t.Errorf("handler chosen = %v", record)
+		}
+		if record := findRecord(records, "hook error"); record == nil || record["level"] != "ERROR" || record["error"] != os.ErrPermission.Error() {
+			t.Errorf("hook error = %v", record)
+		}
+		if record := findRecord(records, "event handled"); record == nil || record["exit_code"] != float64(2) {
+			t.Errorf("event handled = %v", record)
+		}
+	})
+
+	t.Run("unreadable transcript", func(t *testing.T) {
+		buf.Reset()
+		input := `{"hook_event_name": "Stop", "session_id": "test", "transcript_path": "/nonexistent/transcript.jsonl", "stop_hook_active": false}`
+		runner.execute(context.Background(), []byte(input))
+		records := logRecords(t, buf.Bytes())
+
+		if record := findRecord(records, "failed to read transcript"); record == nil || record["level"] != "WARN" {
+			t.Errorf("failed to read transcript = %v", record)
+		}
+		if record := findRecord(records, "event handled"); record == nil || record["exit_code"] != float64(0) {
+			t.Errorf("event handled = %v", record)
+		}
+	})
+}
+
+func TestNewFileLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook.log")
+	newFileLogger(path, slog.LevelDebug).Debug("event received", "event", "Stop")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record := findRecord(logRecords(t, data), "event received"); record == nil || record["event"] != "Stop" {
+		t.Errorf("log file = %s", data)
+	}
+
+	// The default log file only records warnings and errors, in a directory created on demand
+	path = filepath.Join(t.TempDir(), "cchooks", "hooks.log")
+	log := newFileLogger(path, slog.LevelWarn)
+	log.Debug("event received")
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("log directory created before anything was logged: %v", err)
+	}
+	log.Warn("failed to read transcript")
+
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := logRecords(t, data)
+	if len(records) != 1 || findRecord(records, "failed to read transcript") == nil {
+		t.Errorf("log file = %s", data)
+	}
+}
+
+func TestDefaultLogFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+	t.Setenv("HOME", "/home/test")
+	if runtime.GOOS != "linux" {
+		t.Skip("cache directory location is platform specific")
+	}
+	if got := DefaultLogFile(); got != "/cache/cchooks/hooks.log" {
+		t.Errorf("DefaultLogFile() = %q", got)
+	}
+}
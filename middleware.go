@@ -0,0 +1,76 @@
+package cchooks
+
+import (
+	"context"
+	"fmt"
+)
+
+// Invocation describes a single hook event passing through the middleware chain
+type Invocation struct {
+	// EventName is the hook_event_name of the event, e.g. "PreToolUse"
+	EventName string
+	// RawJSON is the JSON payload the hook received
+	RawJSON string
+	// Event is the decoded event, e.g. *PreToolUseEvent or *StopEvent
+	Event interface{}
+}
+
+// HandlerFunc handles an invocation and returns its response
+// The response is the response type for the event (e.g. *PreToolUseResponse),
+// an *ErrorResponse, or nil if the action should be allowed without output.
+type HandlerFunc func(ctx context.Context, inv *Invocation) interface{}
+
+// Middleware wraps the dispatch of every event
+// A middleware can inspect or modify the invocation, call next and inspect the
+// response, or short-circuit by returning its own response without calling next.
+//
+//	runner.Use(func(next cchooks.HandlerFunc) cchooks.HandlerFunc {
+//	    return func(ctx context.Context, inv *cchooks.Invocation) interface{} {
+//	        start := time.Now()
+//	        resp := next(ctx, inv)
+//	        log.Printf("%s took %s", inv.EventName, time.Since(start))
+//	        return resp
+//	    }
+//	})
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Use adds middleware to the Runner
+// Middleware runs after the Raw handler and after the event is decoded. The first
+// middleware registered is the outermost. An *ErrorResponse returned by middleware
+// is passed to the Error handler, like one returned by a handler.
+func (r *Runner) Use(middleware ...Middleware) *Runner {
+	r.middleware = append(r.middleware, middleware...)
+	return r
+}
+
+// invoke runs an invocation through the middleware chain and the event's handler
+// It returns an error if the response does not belong to the invocation's event.
+func (r *Runner) invoke(ctx context.Context, inv *Invocation) (interface{}, error) {
+	handler := HandlerFunc(r.dispatch)
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+
+	response := handler(ctx, inv)
+	if err := checkResponse(inv.EventName, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// checkResponse returns an error if response is not a valid response for the event
+func checkResponse(eventName string, response interface{}) error {
+	switch response.(type) {
+	case nil, *ErrorResponse:
+		return nil
+	case *RawResponse:
+		// Only unrecognised events, which have no typed response, are answered with a RawResponse
+		if newResponse(eventName) == nil {
+			return nil
+		}
+	}
+	if responseEventName(response) != eventName {
+		return fmt.Errorf("invalid response type %T for %s event", response, eventName)
+	}
+	return nil
+}
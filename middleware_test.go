@@ -0,0 +1,132 @@
+package cchooks
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Run("runs in registration order around the handler", func(t *testing.T) {
+		var calls []string
+		trace := func(name string) Middleware {
+			return func(next HandlerFunc) HandlerFunc {
+				return func(ctx context.Context, inv *Invocation) interface{} {
+					calls = append(calls, name+" before")
+					resp := next(ctx, inv)
+					calls = append(calls, name+" after")
+					return resp
+				}
+			}
+		}
+
+		runner := &Runner{
+			Notification: func(ctx context.Context, event *NotificationEvent) NotificationResponseInterface {
+				calls = append(calls, "handler")
+				return OK()
+			},
+		}
+		runner.Use(trace("outer"), trace("inner"))
+
+		NewTestRunner(runner).TestNotification("hello")
+
+		want := []string{"outer before", "inner before", "handler", "inner after", "outer after"}
+		if strings.Join(calls, ",") != strings.Join(want, ",") {
+			t.Errorf("calls = %v, want %v", calls, want)
+		}
+	})
+
+	t.Run("sees the raw JSON, event and response", func(t *testing.T) {
+		runner := &Runner{
+			PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+				return DenyTool("no")
+			},
+		}
+		runner.Use(func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, inv *Invocation) interface{} {
+				if inv.EventName != "PreToolUse" {
+					t.Errorf("EventName = %q", inv.EventName)
+				}
+				if !strings.Contains(inv.RawJSON, `"tool_name":"Bash"`) {
+					t.Errorf("RawJSON = %s", inv.RawJSON)
+				}
+				if event, ok := inv.Event.(*PreToolUseEvent); !ok || event.ToolName != "Bash" {
+					t.Errorf("Event = %#v", inv.Event)
+				}
+
+				resp := next(ctx, inv)
+				if r, ok := resp.(*PreToolUseResponse); !ok || r.PermissionDecision() != PermissionDecisionDeny {
+					t.Errorf("response = %#v", resp)
+				}
+				return resp
+			}
+		})
+
+		if err := NewTestRunner(runner).AssertPreToolUseBlocks("Bash", &BashInput{Command: "ls"}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("short-circuits without calling the handler", func(t *testing.T) {
+		runner := &Runner{
+			PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+				t.Error("handler should not be called")
+				return nil
+			},
+		}
+		runner.Use(func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, inv *Invocation) interface{} {
+				return AllowTool("allowlisted")
+			}
+		})
+
+		if err := NewTestRunner(runner).AssertPreToolUseApproves("Bash", &BashInput{Command: "ls"}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("runs for events without a handler", func(t *testing.T) {
+		runner := &Runner{}
+		runner.Use(func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, inv *Invocation) interface{} {
+				if event, ok := inv.Event.(*PreToolUseEvent); ok && event.ToolName != "Read" {
+					return DenyTool("only Read is allowed")
+				}
+				return next(ctx, inv)
+			}
+		})
+		tr := NewTestRunner(runner)
+
+		if err := tr.AssertPreToolUseBlocks("Bash", &BashInput{Command: "ls"}); err != nil {
+			t.Error(err)
+		}
+		if resp := tr.TestPreToolUse("Read", &ReadInput{FilePath: "/etc/hosts"}); resp != nil {
+			t.Errorf("expected no response, got %#v", resp)
+		}
+		if resp := tr.TestStop(false, nil); resp != nil {
+			t.Errorf("expected no response, got %#v", resp)
+		}
+	})
+
+	t.Run("rejects a response for another event", func(t *testing.T) {
+		runner := &Runner{
+			Stop: func(ctx context.Context, event *StopEvent) StopResponseInterface {
+				return Continue()
+			},
+		}
+		runner.Use(func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, inv *Invocation) interface{} {
+				return Approve()
+			}
+		})
+
+		resp := NewTestRunner(runner).TestStop(false, nil)
+		errResp, ok := resp.(*ErrorResponse)
+		if !ok {
+			t.Fatalf("expected ErrorResponse, got %T", resp)
+		}
+		if !strings.Contains(errResp.Message, "invalid response type *cchooks.PreToolUseResponse for Stop event") {
+			t.Errorf("unexpected error: %s", errResp.Message)
+		}
+	})
+}
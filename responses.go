@@ -1,5 +1,7 @@
 package cchooks
 
+import "encoding/json"
+
 // Response interfaces - these are returned by handlers
 type PreToolUseResponseInterface interface {
 	isPreToolUseResponse()
@@ -17,14 +19,37 @@ type StopResponseInterface interface {
 	isStopResponse()
 }
 
+type SubagentStopResponseInterface interface {
+	isSubagentStopResponse()
+}
+
+type PreCompactResponseInterface interface {
+	isPreCompactResponse()
+}
+
+type UserPromptSubmitResponseInterface interface {
+	isUserPromptSubmitResponse()
+}
+
+type SessionStartResponseInterface interface {
+	isSessionStartResponse()
+}
+
+type SessionEndResponseInterface interface {
+	isSessionEndResponse()
+}
+
 // Response types with event-specific decision options
 
 // PreToolUseResponse is the response for PreToolUse events.
+// Decision and Reason are the legacy approve/block fields; HookSpecificOutput carries
+// the permissionDecision (allow/deny/ask) used by the current hook protocol.
 type PreToolUseResponse struct {
 	Decision   string `json:"decision,omitempty"`
 	Continue   *bool  `json:"continue,omitempty"`
 	StopReason string `json:"stopReason,omitempty"`
 	Reason     string `json:"reason,omitempty"`
+	CommonOutput
 }
 
 // PostToolUseResponse is the response for PostToolUse events.
@@ -33,12 +58,14 @@ type PostToolUseResponse struct {
 	Continue   *bool  `json:"continue,omitempty"`
 	StopReason string `json:"stopReason,omitempty"`
 	Reason     string `json:"reason,omitempty"`
+	CommonOutput
 }
 
 // NotificationResponse is the response for Notification events.
 type NotificationResponse struct {
 	Continue   *bool  `json:"continue,omitempty"`
 	StopReason string `json:"stopReason,omitempty"`
+	CommonOutput
 }
 
 // StopResponse is the response for Stop events.
@@ -47,40 +74,236 @@ type StopResponse struct {
 	Continue   *bool  `json:"continue,omitempty"`
 	StopReason string `json:"stopReason,omitempty"`
 	Reason     string `json:"reason,omitempty"`
+	CommonOutput
+}
+
+// SubagentStopResponse is the response for SubagentStop events.
+type SubagentStopResponse struct {
+	Decision   string `json:"decision,omitempty"`
+	Continue   *bool  `json:"continue,omitempty"`
+	StopReason string `json:"stopReason,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	CommonOutput
+}
+
+// PreCompactResponse is the response for PreCompact events.
+// Claude Code cannot block compaction or take instructions for it from a hook, so only
+// the common fields are available.
+type PreCompactResponse struct {
+	Continue   *bool  `json:"continue,omitempty"`
+	StopReason string `json:"stopReason,omitempty"`
+	CommonOutput
+}
+
+// UserPromptSubmitResponse is the response for UserPromptSubmit events.
+type UserPromptSubmitResponse struct {
+	Decision   string `json:"decision,omitempty"`
+	Continue   *bool  `json:"continue,omitempty"`
+	StopReason string `json:"stopReason,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	CommonOutput
+}
+
+// SessionStartResponse is the response for SessionStart events.
+type SessionStartResponse struct {
+	Continue   *bool  `json:"continue,omitempty"`
+	StopReason string `json:"stopReason,omitempty"`
+	CommonOutput
+}
+
+// SessionEndResponse is the response for SessionEnd events.
+// SessionEnd hooks cannot block the session from ending.
+type SessionEndResponse struct {
+	Continue   *bool  `json:"continue,omitempty"`
+	StopReason string `json:"stopReason,omitempty"`
+	CommonOutput
+}
+
+// CommonOutput holds the output fields every hook event supports.
+// It is embedded in every response type.
+type CommonOutput struct {
+	// SuppressOutput hides the hook's stdout from the transcript
+	SuppressOutput bool `json:"suppressOutput,omitempty"`
+	// SystemMessage is a message shown to the user
+	SystemMessage string `json:"systemMessage,omitempty"`
+	// HookSpecificOutput carries event-specific fields such as additionalContext
+	HookSpecificOutput *HookSpecificOutput `json:"hookSpecificOutput,omitempty"`
+
+	// exitCodeBlock is set by WithExitCodeBlock
+	exitCodeBlock bool
+}
+
+func (c *CommonOutput) commonOutput() *CommonOutput {
+	return c
+}
+
+func (c *CommonOutput) isEmpty() bool {
+	return !c.SuppressOutput && c.SystemMessage == "" && c.HookSpecificOutput == nil
+}
+
+// HookSpecificOutput carries event-specific output fields under the
+// hookSpecificOutput key of a response.
+type HookSpecificOutput struct {
+	HookEventName string `json:"hookEventName"`
+	// PermissionDecision is "allow", "deny" or "ask" (PreToolUse)
+	PermissionDecision       string `json:"permissionDecision,omitempty"`
+	PermissionDecisionReason string `json:"permissionDecisionReason,omitempty"`
+	// UpdatedInput replaces the tool input before the tool runs (PreToolUse)
+	UpdatedInput json.RawMessage `json:"updatedInput,omitempty"`
+	// AdditionalContext is added to the context Claude sees (PreToolUse, PostToolUse, UserPromptSubmit, SessionStart)
+	AdditionalContext string `json:"additionalContext,omitempty"`
 }
 
 // Constants for decisions
 const (
-	PreToolUseApprove = "approve"
-	PreToolUseBlock   = "block"
-	PostToolUseBlock  = "block"
-	StopBlock         = "block"
+	PreToolUseApprove     = "approve"
+	PreToolUseBlock       = "block"
+	PostToolUseBlock      = "block"
+	StopBlock             = "block"
+	SubagentStopBlock     = "block"
+	UserPromptSubmitBlock = "block"
 )
 
+// Constants for PreToolUse permission decisions
+const (
+	PermissionDecisionAllow = "allow"
+	PermissionDecisionDeny  = "deny"
+	PermissionDecisionAsk   = "ask"
+)
+
+// PermissionDecision returns the effective permission decision of the response.
+// hookSpecificOutput.permissionDecision takes precedence; otherwise the legacy decision
+// is mapped ("approve" to "allow", "block" to "deny"). Returns "" if no decision was made.
+func (r *PreToolUseResponse) PermissionDecision() string {
+	if r.HookSpecificOutput != nil && r.HookSpecificOutput.PermissionDecision != "" {
+		return r.HookSpecificOutput.PermissionDecision
+	}
+	switch r.Decision {
+	case PreToolUseApprove:
+		return PermissionDecisionAllow
+	case PreToolUseBlock:
+		return PermissionDecisionDeny
+	}
+	return ""
+}
+
+// PermissionReason returns the reason accompanying the effective permission decision.
+func (r *PreToolUseResponse) PermissionReason() string {
+	if r.HookSpecificOutput != nil && r.HookSpecificOutput.PermissionDecision != "" {
+		return r.HookSpecificOutput.PermissionDecisionReason
+	}
+	return r.Reason
+}
+
+// UpdatedInput returns the replacement tool input carried by the response, or nil if the input is unchanged.
+func (r *PreToolUseResponse) UpdatedInput() json.RawMessage {
+	if r.HookSpecificOutput == nil {
+		return nil
+	}
+	return r.HookSpecificOutput.UpdatedInput
+}
+
 // Interface implementation methods
-func (*PreToolUseResponse) isPreToolUseResponse() {}
-func (*PostToolUseResponse) isPostToolUseResponse() {}
-func (*NotificationResponse) isNotificationResponse() {}
-func (*StopResponse) isStopResponse() {}
+func (*PreToolUseResponse) isPreToolUseResponse()             {}
+func (*PostToolUseResponse) isPostToolUseResponse()           {}
+func (*NotificationResponse) isNotificationResponse()         {}
+func (*StopResponse) isStopResponse()                         {}
+func (*SubagentStopResponse) isSubagentStopResponse()         {}
+func (*PreCompactResponse) isPreCompactResponse()             {}
+func (*UserPromptSubmitResponse) isUserPromptSubmitResponse() {}
+func (*SessionStartResponse) isSessionStartResponse()         {}
+func (*SessionEndResponse) isSessionEndResponse()             {}
 
 // ErrorResponse implements all response interfaces
-func (*ErrorResponse) isPreToolUseResponse() {}
-func (*ErrorResponse) isPostToolUseResponse() {}
-func (*ErrorResponse) isNotificationResponse() {}
-func (*ErrorResponse) isStopResponse() {}
+func (*ErrorResponse) isPreToolUseResponse()       {}
+func (*ErrorResponse) isPostToolUseResponse()      {}
+func (*ErrorResponse) isNotificationResponse()     {}
+func (*ErrorResponse) isStopResponse()             {}
+func (*ErrorResponse) isSubagentStopResponse()     {}
+func (*ErrorResponse) isPreCompactResponse()       {}
+func (*ErrorResponse) isUserPromptSubmitResponse() {}
+func (*ErrorResponse) isSessionStartResponse()     {}
+func (*ErrorResponse) isSessionEndResponse()       {}
 
 // Helper functions for common responses
 
 // Approve creates a PreToolUseResponse that approves the tool use
+// It uses the legacy "approve" decision; see AllowTool for the permissionDecision equivalent
 func Approve() *PreToolUseResponse {
 	return &PreToolUseResponse{Decision: PreToolUseApprove}
 }
 
 // Block creates a PreToolUseResponse that blocks the tool use with a reason
+// It uses the legacy "block" decision; see DenyTool for the permissionDecision equivalent
 func Block(reason string) *PreToolUseResponse {
 	return &PreToolUseResponse{Decision: PreToolUseBlock, Reason: reason}
 }
 
+// AllowTool creates a PreToolUseResponse that allows the tool use, bypassing the permission prompt
+// The reason is shown to the user but not to Claude
+func AllowTool(reason string) *PreToolUseResponse {
+	return permissionResponse(PermissionDecisionAllow, reason)
+}
+
+// DenyTool creates a PreToolUseResponse that prevents the tool use
+// The reason is shown to Claude so it can adjust its approach
+func DenyTool(reason string) *PreToolUseResponse {
+	return permissionResponse(PermissionDecisionDeny, reason)
+}
+
+// AskUser creates a PreToolUseResponse that asks the user to confirm the tool use
+// The reason is shown to the user in the confirmation prompt
+func AskUser(reason string) *PreToolUseResponse {
+	return permissionResponse(PermissionDecisionAsk, reason)
+}
+
+// UpdateInput creates a PreToolUseResponse that asks the user to confirm the tool use with a modified input
+// The input replaces the original tool input entirely, so start from the parsed event input:
+//
+//	bash, _ := event.AsBash()
+//	bash.Command += " --dry-run"
+//	return cchooks.UpdateInput(bash, "forced dry run")
+//
+// The user still sees the permission prompt, for the modified input. To skip the prompt,
+// allow explicitly: cchooks.WithUpdatedInput(cchooks.AllowTool(reason), bash)
+func UpdateInput[T ToolInput](input *T, reason string) *PreToolUseResponse {
+	return WithUpdatedInput(AskUser(reason), input)
+}
+
+// UpdateRawInput creates a PreToolUseResponse that asks the user to confirm the tool use with
+// a replacement input given as raw JSON
+// This is useful for MCP tools and other tools without a typed input
+func UpdateRawInput(input json.RawMessage, reason string) *PreToolUseResponse {
+	return WithUpdatedRawInput(AskUser(reason), input)
+}
+
+// WithUpdatedInput replaces the tool input on any PreToolUse response
+// The permission decision of resp is kept: AllowTool runs the modified input without a
+// prompt, AskUser shows the prompt for it.
+func WithUpdatedInput[T ToolInput](resp *PreToolUseResponse, input *T) *PreToolUseResponse {
+	// Tool input types contain only JSON-safe fields, so marshalling cannot fail
+	data, _ := json.Marshal(input)
+	return WithUpdatedRawInput(resp, data)
+}
+
+// WithUpdatedRawInput replaces the tool input on any PreToolUse response with raw JSON
+func WithUpdatedRawInput(resp *PreToolUseResponse, input json.RawMessage) *PreToolUseResponse {
+	hookSpecificOutputFor(resp).UpdatedInput = input
+	return resp
+}
+
+func permissionResponse(decision, reason string) *PreToolUseResponse {
+	return &PreToolUseResponse{
+		CommonOutput: CommonOutput{
+			HookSpecificOutput: &HookSpecificOutput{
+				HookEventName:            "PreToolUse",
+				PermissionDecision:       decision,
+				PermissionDecisionReason: reason,
+			},
+		},
+	}
+}
+
 // PostBlock creates a PostToolUseResponse that blocks the tool use with a reason
 func PostBlock(reason string) *PostToolUseResponse {
 	return &PostToolUseResponse{Decision: PostToolUseBlock, Reason: reason}
@@ -130,6 +353,190 @@ func StopFromStop(reason string) *StopResponse {
 	return &StopResponse{Continue: &cont, StopReason: reason}
 }
 
+// ContinueSubagent creates an empty SubagentStopResponse that allows the sub-agent to stop
+func ContinueSubagent() *SubagentStopResponse {
+	return &SubagentStopResponse{}
+}
+
+// BlockSubagentStop creates a SubagentStopResponse that keeps the sub-agent working with a reason
+func BlockSubagentStop(reason string) *SubagentStopResponse {
+	return &SubagentStopResponse{Decision: SubagentStopBlock, Reason: reason}
+}
+
+// StopFromSubagentStop creates a SubagentStopResponse that stops Claude
+func StopFromSubagentStop(reason string) *SubagentStopResponse {
+	cont := false
+	return &SubagentStopResponse{Continue: &cont, StopReason: reason}
+}
+
+// AllowCompact creates an empty PreCompactResponse that lets compaction proceed
+func AllowCompact() *PreCompactResponse {
+	return &PreCompactResponse{}
+}
+
+// StopFromPreCompact creates a PreCompactResponse that stops Claude
+func StopFromPreCompact(reason string) *PreCompactResponse {
+	cont := false
+	return &PreCompactResponse{Continue: &cont, StopReason: reason}
+}
+
+// AllowPrompt creates an empty UserPromptSubmitResponse that lets the prompt through
+func AllowPrompt() *UserPromptSubmitResponse {
+	return &UserPromptSubmitResponse{}
+}
+
+// BlockPrompt creates a UserPromptSubmitResponse that blocks the prompt with a reason
+// The reason is shown to the user; the prompt is not sent to Claude
+func BlockPrompt(reason string) *UserPromptSubmitResponse {
+	return &UserPromptSubmitResponse{Decision: UserPromptSubmitBlock, Reason: reason}
+}
+
+// AddPromptContext creates a UserPromptSubmitResponse that allows the prompt and
+// injects additional context alongside it
+func AddPromptContext(context string) *UserPromptSubmitResponse {
+	return WithAdditionalContext(AllowPrompt(), context)
+}
+
+// StopFromPrompt creates a UserPromptSubmitResponse that stops Claude
+func StopFromPrompt(reason string) *UserPromptSubmitResponse {
+	cont := false
+	return &UserPromptSubmitResponse{Continue: &cont, StopReason: reason}
+}
+
+// ContinueSession creates an empty SessionStartResponse that lets the session start normally
+func ContinueSession() *SessionStartResponse {
+	return &SessionStartResponse{}
+}
+
+// AddSessionContext creates a SessionStartResponse that loads additional context into the session
+func AddSessionContext(context string) *SessionStartResponse {
+	return WithAdditionalContext(ContinueSession(), context)
+}
+
+// StopFromSessionStart creates a SessionStartResponse that stops Claude
+func StopFromSessionStart(reason string) *SessionStartResponse {
+	cont := false
+	return &SessionStartResponse{Continue: &cont, StopReason: reason}
+}
+
+// EndSession creates an empty SessionEndResponse
+func EndSession() *SessionEndResponse {
+	return &SessionEndResponse{}
+}
+
+// Builder helpers for common output fields
+// They modify and return the given response, so they can wrap any helper:
+//
+//	return cchooks.WithSystemMessage(cchooks.AskUser("force push"), "Force pushes are audited")
+
+// commonOutputResponse is implemented by every response type through the embedded CommonOutput
+type commonOutputResponse interface {
+	commonOutput() *CommonOutput
+}
+
+// WithSystemMessage sets a message that is shown to the user
+func WithSystemMessage[R commonOutputResponse](resp R, message string) R {
+	resp.commonOutput().SystemMessage = message
+	return resp
+}
+
+// WithSuppressOutput hides the hook's stdout from the transcript
+func WithSuppressOutput[R commonOutputResponse](resp R) R {
+	resp.commonOutput().SuppressOutput = true
+	return resp
+}
+
+// WithExitCodeBlock makes a blocking response exit with code 2 and write its reason to
+// stderr instead of writing JSON to stdout. Claude receives the reason as feedback.
+// Other fields of the response are not output in that case. Responses that do not
+// block are output as JSON as usual. See Runner.ExitCodeBlocks to do this for every response.
+func WithExitCodeBlock[R commonOutputResponse](resp R) R {
+	resp.commonOutput().exitCodeBlock = true
+	return resp
+}
+
+// additionalContextResponse is implemented by the responses of the events whose
+// hookSpecificOutput accepts additionalContext
+type additionalContextResponse interface {
+	commonOutputResponse
+	acceptsAdditionalContext()
+}
+
+func (*PreToolUseResponse) acceptsAdditionalContext()       {}
+func (*PostToolUseResponse) acceptsAdditionalContext()      {}
+func (*UserPromptSubmitResponse) acceptsAdditionalContext() {}
+func (*SessionStartResponse) acceptsAdditionalContext()     {}
+
+// WithAdditionalContext adds context for Claude to consider
+// It accepts PreToolUse, PostToolUse, UserPromptSubmit and SessionStart responses, the
+// events for which Claude Code reads additionalContext.
+func WithAdditionalContext[R additionalContextResponse](resp R, context string) R {
+	hookSpecificOutputFor(resp).AdditionalContext = context
+	return resp
+}
+
+// hookSpecificOutputFor returns the hookSpecificOutput of resp, creating it with the
+// hookEventName matching the response type if it does not exist yet
+func hookSpecificOutputFor(resp commonOutputResponse) *HookSpecificOutput {
+	common := resp.commonOutput()
+	if common.HookSpecificOutput == nil {
+		common.HookSpecificOutput = &HookSpecificOutput{HookEventName: responseEventName(resp)}
+	}
+	return common.HookSpecificOutput
+}
+
+// newResponse returns an empty response for the given hook event name, or nil for unknown events
+func newResponse(eventName string) commonOutputResponse {
+	switch eventName {
+	case "PreToolUse":
+		return &PreToolUseResponse{}
+	case "PostToolUse":
+		return &PostToolUseResponse{}
+	case "Notification":
+		return &NotificationResponse{}
+	case "Stop":
+		return &StopResponse{}
+	case "SubagentStop":
+		return &SubagentStopResponse{}
+	case "PreCompact":
+		return &PreCompactResponse{}
+	case "UserPromptSubmit":
+		return &UserPromptSubmitResponse{}
+	case "SessionStart":
+		return &SessionStartResponse{}
+	case "SessionEnd":
+		return &SessionEndResponse{}
+	default:
+		return nil
+	}
+}
+
+// responseEventName returns the hook event name a response type belongs to
+func responseEventName(resp interface{}) string {
+	switch resp.(type) {
+	case *PreToolUseResponse:
+		return "PreToolUse"
+	case *PostToolUseResponse:
+		return "PostToolUse"
+	case *NotificationResponse:
+		return "Notification"
+	case *StopResponse:
+		return "Stop"
+	case *SubagentStopResponse:
+		return "SubagentStop"
+	case *PreCompactResponse:
+		return "PreCompact"
+	case *UserPromptSubmitResponse:
+		return "UserPromptSubmit"
+	case *SessionStartResponse:
+		return "SessionStart"
+	case *SessionEndResponse:
+		return "SessionEnd"
+	default:
+		return ""
+	}
+}
+
 // RawResponse is the response for the Raw handler
 type RawResponse struct {
 	ExitCode int
@@ -1,6 +1,7 @@
 package cchooks
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -117,4 +118,222 @@ func TestHelpers(t *testing.T) {
 			t.Error("expected only Continue and StopReason fields to be set")
 		}
 	})
+
+	t.Run("AllowPrompt", func(t *testing.T) {
+		resp := AllowPrompt()
+		if resp.Decision != "" || resp.Reason != "" || resp.Continue != nil || resp.StopReason != "" || resp.HookSpecificOutput != nil {
+			t.Error("expected all fields to be empty")
+		}
+	})
+
+	t.Run("BlockPrompt", func(t *testing.T) {
+		resp := BlockPrompt("no ticket")
+		if resp.Decision != UserPromptSubmitBlock {
+			t.Errorf("Decision = %q, want %q", resp.Decision, UserPromptSubmitBlock)
+		}
+		if resp.Reason != "no ticket" {
+			t.Errorf("Reason = %q, want %q", resp.Reason, "no ticket")
+		}
+	})
+
+	t.Run("AddPromptContext", func(t *testing.T) {
+		resp := AddPromptContext("extra")
+		if resp.Decision != "" {
+			t.Errorf("Decision = %q, want empty", resp.Decision)
+		}
+		if resp.HookSpecificOutput == nil {
+			t.Fatal("expected HookSpecificOutput to be set")
+		}
+		if resp.HookSpecificOutput.HookEventName != "UserPromptSubmit" {
+			t.Errorf("HookEventName = %q, want %q", resp.HookSpecificOutput.HookEventName, "UserPromptSubmit")
+		}
+		if resp.HookSpecificOutput.AdditionalContext != "extra" {
+			t.Errorf("AdditionalContext = %q, want %q", resp.HookSpecificOutput.AdditionalContext, "extra")
+		}
+	})
+
+	t.Run("StopFromPrompt", func(t *testing.T) {
+		resp := StopFromPrompt("halt")
+		if resp.Continue == nil || *resp.Continue != false {
+			t.Error("expected Continue to be false")
+		}
+		if resp.StopReason != "halt" {
+			t.Errorf("StopReason = %q, want %q", resp.StopReason, "halt")
+		}
+	})
+
+	t.Run("ContinueSubagent", func(t *testing.T) {
+		resp := ContinueSubagent()
+		if resp.Decision != "" || resp.Reason != "" || resp.Continue != nil || resp.StopReason != "" {
+			t.Error("expected all fields to be empty")
+		}
+	})
+
+	t.Run("BlockSubagentStop", func(t *testing.T) {
+		resp := BlockSubagentStop("keep going")
+		if resp.Decision != SubagentStopBlock {
+			t.Errorf("Decision = %q, want %q", resp.Decision, SubagentStopBlock)
+		}
+		if resp.Reason != "keep going" {
+			t.Errorf("Reason = %q, want %q", resp.Reason, "keep going")
+		}
+	})
+
+	t.Run("StopFromSubagentStop", func(t *testing.T) {
+		resp := StopFromSubagentStop("halt")
+		if resp.Continue == nil || *resp.Continue != false {
+			t.Error("expected Continue to be false")
+		}
+		if resp.StopReason != "halt" {
+			t.Errorf("StopReason = %q, want %q", resp.StopReason, "halt")
+		}
+	})
+
+	t.Run("AddSessionContext", func(t *testing.T) {
+		resp := AddSessionContext("project notes")
+		if resp.HookSpecificOutput == nil {
+			t.Fatal("expected HookSpecificOutput to be set")
+		}
+		if resp.HookSpecificOutput.HookEventName != "SessionStart" {
+			t.Errorf("HookEventName = %q, want %q", resp.HookSpecificOutput.HookEventName, "SessionStart")
+		}
+		if resp.HookSpecificOutput.AdditionalContext != "project notes" {
+			t.Errorf("AdditionalContext = %q, want %q", resp.HookSpecificOutput.AdditionalContext, "project notes")
+		}
+	})
+
+	t.Run("StopFromSessionStart", func(t *testing.T) {
+		resp := StopFromSessionStart("not allowed")
+		if resp.Continue == nil || *resp.Continue != false {
+			t.Error("expected Continue to be false")
+		}
+		if resp.StopReason != "not allowed" {
+			t.Errorf("StopReason = %q, want %q", resp.StopReason, "not allowed")
+		}
+	})
+
+	t.Run("EndSession", func(t *testing.T) {
+		resp := EndSession()
+		if resp.Continue != nil || resp.StopReason != "" {
+			t.Error("expected all fields to be empty")
+		}
+	})
+
+	t.Run("permission decisions", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			resp     *PreToolUseResponse
+			decision string
+		}{
+			{"AllowTool", AllowTool("safe"), PermissionDecisionAllow},
+			{"DenyTool", DenyTool("unsafe"), PermissionDecisionDeny},
+			{"AskUser", AskUser("unsure"), PermissionDecisionAsk},
+		}
+		for _, tt := range tests {
+			if tt.resp.Decision != "" || tt.resp.Reason != "" {
+				t.Errorf("%s: expected legacy fields to be empty", tt.name)
+			}
+			if tt.resp.HookSpecificOutput == nil || tt.resp.HookSpecificOutput.HookEventName != "PreToolUse" {
+				t.Fatalf("%s: HookSpecificOutput = %+v, want PreToolUse output", tt.name, tt.resp.HookSpecificOutput)
+			}
+			if got := tt.resp.PermissionDecision(); got != tt.decision {
+				t.Errorf("%s: PermissionDecision() = %q, want %q", tt.name, got, tt.decision)
+			}
+		}
+		if got := DenyTool("unsafe").PermissionReason(); got != "unsafe" {
+			t.Errorf("PermissionReason() = %q, want %q", got, "unsafe")
+		}
+	})
+
+	t.Run("legacy decisions map to permission decisions", func(t *testing.T) {
+		if got := Approve().PermissionDecision(); got != PermissionDecisionAllow {
+			t.Errorf("Approve().PermissionDecision() = %q, want %q", got, PermissionDecisionAllow)
+		}
+		block := Block("legacy reason")
+		if got := block.PermissionDecision(); got != PermissionDecisionDeny {
+			t.Errorf("Block().PermissionDecision() = %q, want %q", got, PermissionDecisionDeny)
+		}
+		if got := block.PermissionReason(); got != "legacy reason" {
+			t.Errorf("Block().PermissionReason() = %q, want %q", got, "legacy reason")
+		}
+		if got := (&PreToolUseResponse{}).PermissionDecision(); got != "" {
+			t.Errorf("empty PermissionDecision() = %q, want empty", got)
+		}
+	})
+
+	t.Run("UpdateInput", func(t *testing.T) {
+		resp := UpdateInput(&WriteInput{FilePath: "/work/out.txt", Content: "hi"}, "moved into workspace")
+		if got := resp.PermissionDecision(); got != PermissionDecisionAsk {
+			t.Errorf("PermissionDecision() = %q, want %q", got, PermissionDecisionAsk)
+		}
+		if got := string(resp.UpdatedInput()); got != `{"file_path":"/work/out.txt","content":"hi"}` {
+			t.Errorf("UpdatedInput() = %s", got)
+		}
+		if got := resp.PermissionReason(); got != "moved into workspace" {
+			t.Errorf("PermissionReason() = %q, want %q", got, "moved into workspace")
+		}
+	})
+
+	t.Run("UpdateRawInput", func(t *testing.T) {
+		resp := UpdateRawInput(json.RawMessage(`{"repo":"sandbox"}`), "")
+		if got := string(resp.UpdatedInput()); got != `{"repo":"sandbox"}` {
+			t.Errorf("UpdatedInput() = %s", got)
+		}
+		if got := resp.PermissionDecision(); got != PermissionDecisionAsk {
+			t.Errorf("PermissionDecision() = %q, want %q", got, PermissionDecisionAsk)
+		}
+		if Approve().UpdatedInput() != nil {
+			t.Error("expected nil UpdatedInput for legacy response")
+		}
+	})
+
+	t.Run("WithUpdatedInput", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			resp         *PreToolUseResponse
+			wantDecision string
+		}{
+			{"allow", AllowTool("rewritten"), PermissionDecisionAllow},
+			{"ask", AskUser("rewritten"), PermissionDecisionAsk},
+			{"deny", DenyTool("rewritten"), PermissionDecisionDeny},
+			{"no decision", &PreToolUseResponse{}, ""},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				resp := WithUpdatedInput(tt.resp, &BashInput{Command: "make test"})
+				if got := resp.PermissionDecision(); got != tt.wantDecision {
+					t.Errorf("PermissionDecision() = %q, want %q", got, tt.wantDecision)
+				}
+				if got := string(resp.UpdatedInput()); got != `{"command":"make test"}` {
+					t.Errorf("UpdatedInput() = %s", got)
+				}
+				if got := resp.HookSpecificOutput.HookEventName; got != "PreToolUse" {
+					t.Errorf("HookEventName = %q, want PreToolUse", got)
+				}
+			})
+		}
+
+		raw := WithUpdatedRawInput(AllowTool(""), json.RawMessage(`{"repo":"sandbox"}`))
+		if got := string(raw.UpdatedInput()); got != `{"repo":"sandbox"}` || raw.PermissionDecision() != PermissionDecisionAllow {
+			t.Errorf("WithUpdatedRawInput() = %s, %q", got, raw.PermissionDecision())
+		}
+	})
+
+	t.Run("common output builders", func(t *testing.T) {
+		resp := WithSuppressOutput(WithSystemMessage(DenyTool("no"), "blocked rm -rf"))
+		if resp.SystemMessage != "blocked rm -rf" || !resp.SuppressOutput {
+			t.Errorf("unexpected common output: %+v", resp.CommonOutput)
+		}
+		if resp.PermissionDecision() != PermissionDecisionDeny {
+			t.Errorf("builder changed permission decision to %q", resp.PermissionDecision())
+		}
+
+		post := WithAdditionalContext(Allow(), "lint passed")
+		if post.HookSpecificOutput == nil {
+			t.Fatal("expected hookSpecificOutput")
+		}
+		if post.HookSpecificOutput.HookEventName != "PostToolUse" || post.HookSpecificOutput.AdditionalContext != "lint passed" {
+			t.Errorf("unexpected hookSpecificOutput: %+v", post.HookSpecificOutput)
+		}
+	})
 }
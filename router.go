@@ -0,0 +1,111 @@
+package cchooks
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// toolMatcher matches tool names using the same rules as the matcher field
+// in Claude Code's settings file
+type toolMatcher struct {
+	pattern string
+	names   []string       // exact tool names, for patterns made only of names and "|"
+	re      *regexp.Regexp // unanchored expression for any other pattern
+	invalid bool           // the pattern is not a valid regular expression and matches nothing
+}
+
+// namesPattern matches patterns that Claude Code compares as a list of exact tool names
+var namesPattern = regexp.MustCompile(`^[A-Za-z0-9_|]+$`)
+
+// newToolMatcher compiles a matcher pattern
+// An empty pattern or "*" matches every tool. A pattern made only of letters, digits,
+// underscores and "|" is a list of exact tool names, so "Edit|Write" matches exactly
+// Edit and Write. Any other pattern is a regular expression that matches anywhere in
+// the tool name, so "Edit.*" also matches MultiEdit and NotebookEdit. A pattern that
+// is not a valid regular expression matches no tool.
+func newToolMatcher(pattern string) toolMatcher {
+	m := toolMatcher{pattern: pattern}
+	switch {
+	case pattern == "" || pattern == "*":
+	case namesPattern.MatchString(pattern):
+		m.names = strings.Split(pattern, "|")
+	default:
+		re, err := regexp.Compile(pattern)
+		m.re, m.invalid = re, err != nil
+	}
+	return m
+}
+
+func (m toolMatcher) match(toolName string) bool {
+	switch {
+	case m.invalid:
+		return false
+	case m.names != nil:
+		return slices.Contains(m.names, toolName)
+	case m.re != nil:
+		return m.re.MatchString(toolName)
+	default:
+		return true
+	}
+}
+
+type preToolUseRoute struct {
+	matcher toolMatcher
+	handler func(context.Context, *PreToolUseEvent) PreToolUseResponseInterface
+}
+
+type postToolUseRoute struct {
+	matcher toolMatcher
+	handler func(context.Context, *PostToolUseEvent) PostToolUseResponseInterface
+}
+
+// OnPreToolUse registers a PreToolUse handler for tools whose name matches the matcher
+// Routes are evaluated in registration order and the first match handles the event.
+// If no route matches, the PreToolUse field is used as the catch-all.
+// Matchers follow Claude Code's rules: "Edit|Write" lists exact tool names, other
+// regular expressions such as "mcp__.*" match anywhere in the name, and an invalid
+// regular expression matches no tool.
+func (r *Runner) OnPreToolUse(matcher string, handler func(context.Context, *PreToolUseEvent) PreToolUseResponseInterface) *Runner {
+	r.preToolUseRoutes = append(r.preToolUseRoutes, preToolUseRoute{
+		matcher: newToolMatcher(matcher),
+		handler: handler,
+	})
+	return r
+}
+
+// OnPostToolUse registers a PostToolUse handler for tools whose name matches the matcher
+// Routes are evaluated in registration order and the first match handles the event.
+// If no route matches, the PostToolUse field is used as the catch-all.
+// Matchers follow the same rules as OnPreToolUse.
+func (r *Runner) OnPostToolUse(matcher string, handler func(context.Context, *PostToolUseEvent) PostToolUseResponseInterface) *Runner {
+	r.postToolUseRoutes = append(r.postToolUseRoutes, postToolUseRoute{
+		matcher: newToolMatcher(matcher),
+		handler: handler,
+	})
+	return r
+}
+
+// preToolUseHandler returns the handler for a PreToolUse event on the given tool, or nil if there is none
+// The label names the route or field the handler comes from, for logging.
+func (r *Runner) preToolUseHandler(toolName string) (func(context.Context, *PreToolUseEvent) PreToolUseResponseInterface, string) {
+	for _, route := range r.preToolUseRoutes {
+		if route.matcher.match(toolName) {
+			return route.handler, fmt.Sprintf("OnPreToolUse(%q)", route.matcher.pattern)
+		}
+	}
+	return r.PreToolUse, handlerLabel("PreToolUse", r.PreToolUse != nil)
+}
+
+// postToolUseHandler returns the handler for a PostToolUse event on the given tool, or nil if there is none
+// The label names the route or field the handler comes from, for logging.
+func (r *Runner) postToolUseHandler(toolName string) (func(context.Context, *PostToolUseEvent) PostToolUseResponseInterface, string) {
+	for _, route := range r.postToolUseRoutes {
+		if route.matcher.match(toolName) {
+			return route.handler, fmt.Sprintf("OnPostToolUse(%q)", route.matcher.pattern)
+		}
+	}
+	return r.PostToolUse, handlerLabel("PostToolUse", r.PostToolUse != nil)
+}
@@ -0,0 +1,98 @@
+package cchooks
+
+import (
+	"context"
+	"testing"
+)
+
+func TestToolMatcher(t *testing.T) {
+	tests := []struct {
+		matcher  string
+		toolName string
+		want     bool
+	}{
+		{"", "Bash", true},
+		{"*", "mcp__github__create_issue", true},
+		{"Bash", "Bash", true},
+		{"Bash", "BashOutput", false},
+		{"Edit|Write|MultiEdit", "Write", true},
+		{"Edit|Write|MultiEdit", "NotebookEdit", false},
+		{"Notebook.*", "NotebookEdit", true},
+		{"mcp__github__.*", "mcp__github__create_issue", true},
+		{"mcp__github__.*", "mcp__gitlab__create_issue", false},
+		{"bash", "Bash", false},
+		{"Edit", "MultiEdit", false},
+		{"Edit.*", "MultiEdit", true},
+		{"^Edit$", "MultiEdit", false},
+		{"mcp__.*__create", "mcp__github__create_issue", true},
+		{"Edit(", "Edit(", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.matcher+" "+tt.toolName, func(t *testing.T) {
+			if got := newToolMatcher(tt.matcher).match(tt.toolName); got != tt.want {
+				t.Errorf("match(%q) with matcher %q = %v, want %v", tt.toolName, tt.matcher, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunnerRoutes(t *testing.T) {
+	var called []string
+	runner := &Runner{
+		PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+			called = append(called, "fallback")
+			return Approve()
+		},
+	}
+	runner.
+		OnPreToolUse("Edit|Write", func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+			called = append(called, "edit")
+			return Block("read-only")
+		}).
+		OnPreToolUse("Write", func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+			called = append(called, "write")
+			return Approve()
+		}).
+		OnPostToolUse("mcp__github__.*", func(ctx context.Context, event *PostToolUseEvent) PostToolUseResponseInterface {
+			called = append(called, "github")
+			return Allow()
+		})
+
+	tester := NewTestRunner(runner)
+
+	t.Run("first matching route wins", func(t *testing.T) {
+		called = nil
+		if err := tester.AssertPreToolUseBlocks("Write", &WriteInput{FilePath: "a.txt"}); err != nil {
+			t.Error(err)
+		}
+		if len(called) != 1 || called[0] != "edit" {
+			t.Errorf("called = %v, want [edit]", called)
+		}
+	})
+
+	t.Run("PreToolUse field is the catch-all", func(t *testing.T) {
+		called = nil
+		if err := tester.AssertPreToolUseApproves("Bash", &BashInput{Command: "ls"}); err != nil {
+			t.Error(err)
+		}
+		if len(called) != 1 || called[0] != "fallback" {
+			t.Errorf("called = %v, want [fallback]", called)
+		}
+	})
+
+	t.Run("PostToolUse route", func(t *testing.T) {
+		called = nil
+		tester.TestPostToolUse("mcp__github__create_issue", map[string]string{}, map[string]string{})
+		if len(called) != 1 || called[0] != "github" {
+			t.Errorf("called = %v, want [github]", called)
+		}
+	})
+
+	t.Run("no matching route and no catch-all", func(t *testing.T) {
+		resp := tester.TestPostToolUse("Bash", &BashInput{Command: "ls"}, map[string]string{})
+		if _, ok := resp.(*ErrorResponse); !ok {
+			t.Errorf("expected ErrorResponse, got %T", resp)
+		}
+	})
+}
@@ -1,12 +1,15 @@
 package cchooks
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -23,15 +26,64 @@ type Runner struct {
 	// StopOnce is called for Stop events only when stop_hook_active is false
 	// This allows hooks to handle the first stop event differently
 	// If both Stop and StopOnce are defined, StopOnce takes precedence when stop_hook_active is false
-	StopOnce func(context.Context, *StopEvent) StopResponseInterface
+	StopOnce     func(context.Context, *StopEvent) StopResponseInterface
+	SubagentStop func(context.Context, *SubagentStopEvent) SubagentStopResponseInterface
+	// SubagentStopOnce is called for SubagentStop events only when stop_hook_active is false
+	// If both SubagentStop and SubagentStopOnce are defined, SubagentStopOnce takes precedence when stop_hook_active is false
+	SubagentStopOnce func(context.Context, *SubagentStopEvent) SubagentStopResponseInterface
+	PreCompact       func(context.Context, *PreCompactEvent) PreCompactResponseInterface
+	UserPromptSubmit func(context.Context, *UserPromptSubmitEvent) UserPromptSubmitResponseInterface
+	SessionStart     func(context.Context, *SessionStartEvent) SessionStartResponseInterface
+	SessionEnd       func(context.Context, *SessionEndEvent) SessionEndResponseInterface
+	// Unknown is called for event types the SDK does not recognise, such as events added
+	// by newer versions of Claude Code. A non-nil RawResponse is written as-is; nil exits 0
+	// without output. If Unknown is not set, UnknownEvents decides what happens.
+	Unknown func(context.Context, *GenericEvent) *RawResponse
+	// UnknownEvents is the policy for unrecognised events when Unknown is not set
+	// The zero value, UnknownEventPassThrough, exits 0 without output.
+	UnknownEvents UnknownEventPolicy
 	// Error is called when any error occurs inside the SDK
 	// It receives the raw JSON string that was passed to the hook and the error
 	// If it returns a non-nil RawResponse, that response is used instead of the default error handling
 	// If it returns nil, the SDK will use exit code 2 and output the error to stderr
 	Error func(ctx context.Context, rawJSON string, err error) *RawResponse
 
+	// HandlerTimeout is the deadline for the middleware and handler processing an event
+	// Zero means no deadline. When it expires, Error is called with a *TimeoutError; if Error
+	// returns nil, the TimeoutFallback response is output instead.
+	HandlerTimeout time.Duration
+	// TimeoutFallback returns the response used when a handler times out
+	// It defaults to DefaultTimeoutFallback, which denies PreToolUse and allows everything else.
+	TimeoutFallback func(ctx context.Context, inv *Invocation) interface{}
+	// StdinTimeout is how long to wait for the event on stdin; zero uses DefaultStdinTimeout
+	StdinTimeout time.Duration
+
+	// ExitCodeBlocks emits block decisions by exiting with code 2 and writing the reason to
+	// stderr instead of writing JSON to stdout, for older Claude Code versions and simpler
+	// consumers. Other responses are output as JSON. Use WithExitCodeBlock for a single response.
+	ExitCodeBlocks bool
+
+	// Logger records events received, handlers chosen, decisions, durations and errors the
+	// SDK would otherwise swallow. If nil, every log is written to the file named by the
+	// CCHOOKS_LOG_FILE environment variable, or, if it is not set, warnings and errors are
+	// written to DefaultLogFile.
+	Logger *slog.Logger
+
+	// Stdin, Stdout and Stderr are the streams Run and RunContext use for the hook protocol
+	// They default to the process's standard streams. Execute does not use them.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
 	// ExitFn is used for exiting the process. It defaults to os.Exit but can be overridden in tests.
 	ExitFn func(int)
+
+	// Tool routes registered with OnPreToolUse and OnPostToolUse
+	preToolUseRoutes  []preToolUseRoute
+	postToolUseRoutes []postToolUseRoute
+
+	// Middleware registered with Use
+	middleware []Middleware
 }
 
 // Run reads from stdin, dispatches to appropriate handler, outputs response
@@ -48,8 +100,45 @@ func (r *Runner) RunContext(ctx context.Context) {
 		r.ExitFn = os.Exit
 	}
 
+	r.writeResult(r.Execute(ctx, r.stdin()))
+}
+
+// Execute reads one hook input from input, handles it and returns the output and exit
+// code the hook process should produce. It does not write to the Runner's streams or
+// exit, so hooks can be embedded in other programs and executed concurrently.
+// Reading the input is subject to StdinTimeout.
+func (r *Runner) Execute(ctx context.Context, input io.Reader) Result {
 	// Read all input with timeout
-	var rawJSON []byte
+	rawJSON, err := r.readInput(input)
+	if err != nil {
+		return r.errorResult(ctx, "", "", err)
+	}
+	return r.execute(ctx, rawJSON)
+}
+
+func (r *Runner) stdin() io.Reader {
+	if r.Stdin != nil {
+		return r.Stdin
+	}
+	return os.Stdin
+}
+
+func (r *Runner) stdout() io.Writer {
+	if r.Stdout != nil {
+		return r.Stdout
+	}
+	return os.Stdout
+}
+
+func (r *Runner) stderr() io.Writer {
+	if r.Stderr != nil {
+		return r.Stderr
+	}
+	return os.Stderr
+}
+
+// readInput reads the hook input, giving up after the stdin timeout
+func (r *Runner) readInput(stdin io.Reader) ([]byte, error) {
 	type readResult struct {
 		data []byte
 		err  error
@@ -58,7 +147,7 @@ func (r *Runner) RunContext(ctx context.Context) {
 
 	// Read stdin in a goroutine
 	go func() {
-		data, err := io.ReadAll(os.Stdin)
+		data, err := io.ReadAll(stdin)
 		readChan <- readResult{data, err}
 	}()
 
@@ -66,23 +155,35 @@ func (r *Runner) RunContext(ctx context.Context) {
 	select {
 	case result := <-readChan:
 		if result.err != nil {
-			r.handleError(ctx, "", fmt.Errorf("failed to read stdin: %w", result.err))
-			return
+			return nil, fmt.Errorf("failed to read stdin: %w", result.err)
 		}
-		rawJSON = result.data
-	case <-time.After(1 * time.Second):
-		r.handleError(ctx, "", fmt.Errorf("timeout reading stdin"))
-		return
+		return result.data, nil
+	case <-time.After(r.stdinTimeout()):
+		return nil, fmt.Errorf("timeout reading stdin")
 	}
+}
+
+// execute handles one hook input and returns the exit code and output for Claude Code
+// It does not touch the process's standard streams or exit, so it can also serve daemon requests.
+func (r *Runner) execute(ctx context.Context, rawJSON []byte) (result Result) {
+	// Find the event type (hook_event_name is the actual field name used by Claude Code)
+	// once, so that errors and panics can be reported without parsing the input again.
+	// A failure is reported after the Raw handler has had a chance to handle the input.
+	eventName, peekErr := peekEventName(rawJSON)
+
+	log := r.logger()
+	log.DebugContext(ctx, "event received", "event", eventName, "bytes", len(rawJSON))
+
+	// Record the outcome once any panic has been recovered
+	start := time.Now()
+	var decision string
+	defer func() {
+		log.InfoContext(ctx, "event handled", "event", eventName, "decision", decision, "exit_code", result.ExitCode, "duration", time.Since(start))
+	}()
 
 	// Set up panic recovery
 	defer func() {
 		if p := recover(); p != nil {
-			// Don't catch test exit panics
-			if p == "exit" {
-				panic(p)
-			}
-
 			// Convert panic to error
 			var err error
 			switch v := p.(type) {
@@ -94,228 +195,321 @@ func (r *Runner) RunContext(ctx context.Context) {
 				err = fmt.Errorf("panic: %v", v)
 			}
 
-			// Handle error using handleError which will use Error handler if available
-			r.handleError(ctx, string(rawJSON), err)
+			// Handle error using errorResult which will use Error handler if available
+			result = r.errorResult(ctx, eventName, string(rawJSON), err)
 		}
 	}()
 
 	// Call Raw handler if provided
 	if r.Raw != nil {
-		response := r.Raw(ctx, string(rawJSON))
-
-		// If Raw handler returns a response, use it and exit
-		if response != nil {
-			if response.Output != "" {
-				fmt.Fprint(os.Stdout, response.Output)
-			}
-			r.ExitFn(response.ExitCode)
+		// If Raw handler returns a response, use it
+		if response := r.Raw(ctx, string(rawJSON)); response != nil {
+			decision = responseDecision(response)
+			return rawResult(response)
 		}
 		// If Raw handler returns nil, continue with normal processing
 	}
 
-	// Parse JSON
-	var rawEvent map[string]interface{}
-	if err := json.Unmarshal(rawJSON, &rawEvent); err != nil {
-		err = fmt.Errorf("failed to decode stdin: %w", err)
-		r.handleError(ctx, string(rawJSON), err)
-		return // handleError exits, so this is unreachable
+	if peekErr != nil {
+		return r.errorResult(ctx, "", string(rawJSON), peekErr)
 	}
 
-	// Check for hook_event_name field (the actual field name used by Claude Code)
-	event, ok := rawEvent["hook_event_name"].(string)
-	if !ok {
-		err := fmt.Errorf("missing or invalid hook_event_name field")
-		r.handleError(ctx, string(rawJSON), err)
-		return // handleError exits, so this is unreachable
+	// Events that nothing will see are passed through undecoded, so that a payload the SDK
+	// cannot decode doesn't block an event the hook doesn't care about
+	if !r.observes(eventName) {
+		log.DebugContext(ctx, "no handler for event", "event", eventName)
+		return Result{}
 	}
 
-	// Dispatch to appropriate handler
-	var dispatchErr error
-	switch event {
-	case "PreToolUse":
-		dispatchErr = r.handlePreToolUse(ctx, rawEvent, string(rawJSON))
-	case "PostToolUse":
-		dispatchErr = r.handlePostToolUse(ctx, rawEvent, string(rawJSON))
-	case "Notification":
-		dispatchErr = r.handleNotification(ctx, rawEvent, string(rawJSON))
-	case "Stop":
-		dispatchErr = r.handleStop(ctx, rawEvent, string(rawJSON))
-	default:
-		dispatchErr = fmt.Errorf("unknown event type: %s", event)
+	// Decode the typed event
+	decoded, err := decodeEvent(eventName, rawJSON, log)
+	if err != nil {
+		return r.errorResult(ctx, eventName, string(rawJSON), err)
 	}
 
-	if dispatchErr != nil {
-		r.handleError(ctx, string(rawJSON), dispatchErr)
-		return // handleError exits, so this is unreachable
+	// Dispatch through the middleware chain to the appropriate handler
+	inv := &Invocation{
+		EventName: eventName,
+		RawJSON:   string(rawJSON),
+		Event:     decoded,
 	}
-
-	// Success - exit with code 0
-	r.ExitFn(0)
-}
-
-func (r *Runner) handlePreToolUse(ctx context.Context, rawEvent map[string]interface{}, rawJSON string) error {
-	if r.PreToolUse == nil {
-		return nil
+	response, err := r.invokeWithDeadline(ctx, inv)
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		response, err = r.handleTimeout(ctx, inv, timeoutErr)
 	}
-
-	// Parse event
-	eventData, err := json.Marshal(rawEvent)
 	if err != nil {
-		return err
+		return r.errorResult(ctx, eventName, string(rawJSON), err)
 	}
+	decision = responseDecision(response)
 
-	var event PreToolUseEvent
-	if err := json.Unmarshal(eventData, &event); err != nil {
-		return fmt.Errorf("failed to parse PreToolUseEvent: %w", err)
+	// Unknown events and the Error handler's timeout response are answered with a RawResponse
+	if raw, ok := response.(*RawResponse); ok {
+		return rawResult(raw)
 	}
-
-	// Call handler
-	response := r.PreToolUse(ctx, &event)
-
-	// Handle response
-	if err := outputResponse(response); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (r *Runner) handlePostToolUse(ctx context.Context, rawEvent map[string]interface{}, rawJSON string) error {
-	if r.PostToolUse == nil {
-		return nil
+	if reason, ok := r.exitCodeBlock(response); ok {
+		return Result{ExitCode: 2, Stderr: reason + "\n"}
 	}
 
-	// Parse event
-	eventData, err := json.Marshal(rawEvent)
+	output, err := encodeResponse(response)
 	if err != nil {
-		return err
+		return r.errorResult(ctx, eventName, string(rawJSON), err)
 	}
 
-	var event PostToolUseEvent
-	if err := json.Unmarshal(eventData, &event); err != nil {
-		return fmt.Errorf("failed to parse PostToolUseEvent: %w", err)
-	}
+	// Success - exit with code 0
+	return Result{Stdout: string(output)}
+}
 
-	// Call handler
-	response := r.PostToolUse(ctx, &event)
+// peekEventName returns the hook_event_name of the input without decoding the rest of the event
+func peekEventName(rawJSON []byte) (string, error) {
+	var header struct {
+		HookEventName json.RawMessage `json:"hook_event_name"`
+	}
+	if err := json.Unmarshal(rawJSON, &header); err != nil {
+		return "", fmt.Errorf("failed to decode stdin: %w", err)
+	}
 
-	// Handle response
-	if err := outputResponse(response); err != nil {
-		return err
+	var eventName string
+	if header.HookEventName == nil || json.Unmarshal(header.HookEventName, &eventName) != nil || eventName == "" {
+		return "", fmt.Errorf("missing or invalid hook_event_name field")
 	}
-	return nil
+	return eventName, nil
 }
 
-func (r *Runner) handleNotification(ctx context.Context, rawEvent map[string]interface{}, rawJSON string) error {
-	if r.Notification == nil {
-		return nil
+// decodeEvent decodes the input directly into the typed event for its hook_event_name
+// Unrecognised events are decoded as a *GenericEvent. Events that carry a transcript load it when the handler first asks for it
+func decodeEvent(eventName string, rawJSON []byte, log *slog.Logger) (interface{}, error) {
+	var event interface{}
+	switch eventName {
+	case "PreToolUse":
+		event = &PreToolUseEvent{}
+	case "PostToolUse":
+		event = &PostToolUseEvent{}
+	case "Notification":
+		event = &NotificationEvent{}
+	case "Stop":
+		event = &StopEvent{}
+	case "SubagentStop":
+		event = &SubagentStopEvent{}
+	case "PreCompact":
+		event = &PreCompactEvent{}
+	case "UserPromptSubmit":
+		event = &UserPromptSubmitEvent{}
+	case "SessionStart":
+		event = &SessionStartEvent{}
+	case "SessionEnd":
+		event = &SessionEndEvent{}
+	default:
+		event = &GenericEvent{}
 	}
 
 	// Parse event
-	eventData, err := json.Marshal(rawEvent)
-	if err != nil {
-		return err
+	if err := json.Unmarshal(rawJSON, event); err != nil {
+		return nil, fmt.Errorf("failed to parse %sEvent: %w", eventName, err)
 	}
+	bindRaw(event, rawJSON)
 
-	var event NotificationEvent
-	if err := json.Unmarshal(eventData, &event); err != nil {
-		return fmt.Errorf("failed to parse NotificationEvent: %w", err)
+	switch e := event.(type) {
+	case *StopEvent:
+		e.transcript = newTranscriptLoader(e.TranscriptPath, log)
+	case *SubagentStopEvent:
+		e.transcript = newTranscriptLoader(e.TranscriptPath, log)
+	case *PreCompactEvent:
+		e.transcript = newTranscriptLoader(e.TranscriptPath, log)
 	}
 
-	// Call handler
-	response := r.Notification(ctx, &event)
+	return event, nil
+}
+
+// dispatch calls the handler registered for the invocation's event
+// It is the innermost HandlerFunc of the middleware chain and returns nil if no handler is registered.
+func (r *Runner) dispatch(ctx context.Context, inv *Invocation) interface{} {
+	r.logDispatch(ctx, inv)
 
-	// Handle response
-	if err := outputResponse(response); err != nil {
-		return err
+	switch event := inv.Event.(type) {
+	case *PreToolUseEvent:
+		if handler, _ := r.preToolUseHandler(event.ToolName); handler != nil {
+			return handler(ctx, event)
+		}
+	case *PostToolUseEvent:
+		if handler, _ := r.postToolUseHandler(event.ToolName); handler != nil {
+			return handler(ctx, event)
+		}
+	case *NotificationEvent:
+		if r.Notification != nil {
+			return r.Notification(ctx, event)
+		}
+	case *StopEvent:
+		if handler, _ := r.stopHandler(event.StopHookActive); handler != nil {
+			return handler(ctx, event)
+		}
+	case *SubagentStopEvent:
+		if handler, _ := r.subagentStopHandler(event.StopHookActive); handler != nil {
+			return handler(ctx, event)
+		}
+	case *PreCompactEvent:
+		if r.PreCompact != nil {
+			return r.PreCompact(ctx, event)
+		}
+	case *UserPromptSubmitEvent:
+		if r.UserPromptSubmit != nil {
+			return r.UserPromptSubmit(ctx, event)
+		}
+	case *SessionStartEvent:
+		if r.SessionStart != nil {
+			return r.SessionStart(ctx, event)
+		}
+	case *SessionEndEvent:
+		if r.SessionEnd != nil {
+			return r.SessionEnd(ctx, event)
+		}
+	case *GenericEvent:
+		return r.unknownHandler(ctx, event)
 	}
 	return nil
 }
 
-func (r *Runner) handleStop(ctx context.Context, rawEvent map[string]interface{}, rawJSON string) error {
-	// Parse event first to check stop_hook_active
-	eventData, err := json.Marshal(rawEvent)
-	if err != nil {
-		return err
+// observes reports whether middleware or a handler would see an event with the given name
+// Tool routes and StopOnce handlers count without looking at the event's fields.
+func (r *Runner) observes(eventName string) bool {
+	if len(r.middleware) > 0 {
+		return true
 	}
 
-	var event StopEvent
-	if err := json.Unmarshal(eventData, &event); err != nil {
-		return fmt.Errorf("failed to parse StopEvent: %w", err)
-	}
-
-	// Read and parse transcript if transcript_path is provided
-	if event.TranscriptPath != "" {
-		transcript, err := readTranscript(event.TranscriptPath)
-		if err != nil {
-			// Log error but don't fail - transcript is optional enrichment
-			// The handler can still work without it
-			event.Transcript = []TranscriptEntry{}
-		} else {
-			event.Transcript = transcript
-		}
-	} else {
-		// Ensure transcript is never nil
-		event.Transcript = []TranscriptEntry{}
+	switch eventName {
+	case "PreToolUse":
+		return r.PreToolUse != nil || len(r.preToolUseRoutes) > 0
+	case "PostToolUse":
+		return r.PostToolUse != nil || len(r.postToolUseRoutes) > 0
+	case "Notification":
+		return r.Notification != nil
+	case "Stop":
+		return r.Stop != nil || r.StopOnce != nil
+	case "SubagentStop":
+		return r.SubagentStop != nil || r.SubagentStopOnce != nil
+	case "PreCompact":
+		return r.PreCompact != nil
+	case "UserPromptSubmit":
+		return r.UserPromptSubmit != nil
+	case "SessionStart":
+		return r.SessionStart != nil
+	case "SessionEnd":
+		return r.SessionEnd != nil
+	default:
+		return r.Unknown != nil || r.UnknownEvents == UnknownEventError
 	}
+}
 
-	// Determine which handler to use
-	var handler func(context.Context, *StopEvent) StopResponseInterface
-
-	// If stop_hook_active is false and StopOnce is defined, use StopOnce
-	if !event.StopHookActive && r.StopOnce != nil {
-		handler = r.StopOnce
-	} else if r.Stop != nil {
-		// Otherwise use the regular Stop handler if defined
-		handler = r.Stop
+// stopHandler returns the handler for a Stop event and its label, or nil if there is none
+// If stop_hook_active is false and StopOnce is defined, StopOnce is used
+func (r *Runner) stopHandler(stopHookActive bool) (func(context.Context, *StopEvent) StopResponseInterface, string) {
+	if !stopHookActive && r.StopOnce != nil {
+		return r.StopOnce, "StopOnce"
 	}
+	return r.Stop, handlerLabel("Stop", r.Stop != nil)
+}
 
-	// If no appropriate handler is found, return nil
-	if handler == nil {
-		return nil
+// subagentStopHandler returns the handler for a SubagentStop event, mirroring stopHandler
+func (r *Runner) subagentStopHandler(stopHookActive bool) (func(context.Context, *SubagentStopEvent) SubagentStopResponseInterface, string) {
+	if !stopHookActive && r.SubagentStopOnce != nil {
+		return r.SubagentStopOnce, "SubagentStopOnce"
 	}
+	return r.SubagentStop, handlerLabel("SubagentStop", r.SubagentStop != nil)
+}
 
-	// Call the selected handler
-	response := handler(ctx, &event)
-
-	// Handle response
-	if err := outputResponse(response); err != nil {
-		return err
+// encodeResponse returns the JSON output for a response
+// Empty and nil responses produce no output. An *ErrorResponse returns its error.
+func encodeResponse(response interface{}) ([]byte, error) {
+	// No handler or a nil response allows the action
+	if response == nil {
+		return nil, nil
 	}
-	return nil
-}
 
-func outputResponse(response interface{}) error {
 	// Check if it's an error response
 	if errResp, ok := response.(*ErrorResponse); ok {
-		return errResp.Error
+		return nil, errResp.Error
 	}
-	
+
 	// Check if response is empty (allow action)
 	if isEmpty(response) {
 		// Empty response uses exit code 0
-		return nil
+		return nil, nil
+	}
+
+	// hookSpecificOutput requires hookEventName; fill it in for hand-built responses
+	if resp, ok := response.(commonOutputResponse); ok {
+		if out := resp.commonOutput().HookSpecificOutput; out != nil && out.HookEventName == "" {
+			out.HookEventName = responseEventName(response)
+		}
 	}
 
 	// Non-empty response uses JSON output
-	encoder := json.NewEncoder(os.Stdout)
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
 	encoder.SetIndent("", "  ")
 	if err := encoder.Encode(response); err != nil {
-		return fmt.Errorf("failed to encode response: %w", err)
+		return nil, fmt.Errorf("failed to encode response: %w", err)
 	}
 
-	return nil
+	return buf.Bytes(), nil
+}
+
+// defaultBlockReason is written to stderr for an exit code block without a reason
+const defaultBlockReason = "blocked by hook"
+
+// exitCodeBlock returns the reason of a blocking response that should be emitted with exit code 2
+// An empty reason is replaced with defaultBlockReason, since stderr is all Claude sees.
+func (r *Runner) exitCodeBlock(response interface{}) (string, bool) {
+	resp, ok := response.(commonOutputResponse)
+	if !ok || !(r.ExitCodeBlocks || resp.commonOutput().exitCodeBlock) {
+		return "", false
+	}
+	reason, ok := blockReason(response)
+	if ok && strings.TrimSpace(reason) == "" {
+		reason = defaultBlockReason
+	}
+	return reason, ok
+}
+
+// blockReason returns the reason of a response that blocks its event
+// PreToolUse responses block when the permission decision is deny; other events block with a block decision.
+func blockReason(response interface{}) (string, bool) {
+	var decision, reason string
+	switch v := response.(type) {
+	case *PreToolUseResponse:
+		return v.PermissionReason(), v.PermissionDecision() == PermissionDecisionDeny
+	case *PostToolUseResponse:
+		decision, reason = v.Decision, v.Reason
+	case *StopResponse:
+		decision, reason = v.Decision, v.Reason
+	case *SubagentStopResponse:
+		decision, reason = v.Decision, v.Reason
+	case *UserPromptSubmitResponse:
+		decision, reason = v.Decision, v.Reason
+	}
+	return reason, decision == "block"
 }
 
 func isEmpty(response interface{}) bool {
 	switch v := response.(type) {
 	case *PreToolUseResponse:
-		return v.Decision == "" && v.Continue == nil && v.StopReason == "" && v.Reason == ""
+		return v.Decision == "" && v.Continue == nil && v.StopReason == "" && v.Reason == "" && v.CommonOutput.isEmpty()
 	case *PostToolUseResponse:
-		return v.Decision == "" && v.Continue == nil && v.StopReason == "" && v.Reason == ""
+		return v.Decision == "" && v.Continue == nil && v.StopReason == "" && v.Reason == "" && v.CommonOutput.isEmpty()
 	case *NotificationResponse:
-		return v.Continue == nil && v.StopReason == ""
+		return v.Continue == nil && v.StopReason == "" && v.CommonOutput.isEmpty()
 	case *StopResponse:
-		return v.Decision == "" && v.Continue == nil && v.StopReason == "" && v.Reason == ""
+		return v.Decision == "" && v.Continue == nil && v.StopReason == "" && v.Reason == "" && v.CommonOutput.isEmpty()
+	case *SubagentStopResponse:
+		return v.Decision == "" && v.Continue == nil && v.StopReason == "" && v.Reason == "" && v.CommonOutput.isEmpty()
+	case *PreCompactResponse:
+		return v.Continue == nil && v.StopReason == "" && v.CommonOutput.isEmpty()
+	case *UserPromptSubmitResponse:
+		return v.Decision == "" && v.Continue == nil && v.StopReason == "" && v.Reason == "" && v.CommonOutput.isEmpty()
+	case *SessionStartResponse:
+		return v.Continue == nil && v.StopReason == "" && v.CommonOutput.isEmpty()
+	case *SessionEndResponse:
+		return v.Continue == nil && v.StopReason == "" && v.CommonOutput.isEmpty()
 	case *ErrorResponse:
 		return false // ErrorResponse is never empty
 	default:
@@ -323,71 +517,110 @@ func isEmpty(response interface{}) bool {
 	}
 }
 
-// handleError calls the Error handler if available and handles the response
+// rawResult converts a RawResponse from the Raw, Unknown or Error handler into a Result
+func rawResult(response *RawResponse) Result {
+	return Result{ExitCode: response.ExitCode, Stdout: response.Output}
+}
+
+// writeResult writes a Result to the Runner's stdout and stderr and exits with its exit code
+func (r *Runner) writeResult(result Result) {
+	if result.Stdout != "" {
+		if _, err := io.WriteString(r.stdout(), result.Stdout); err != nil {
+			r.logger().Error("failed to write response", "error", err)
+			fmt.Fprintf(r.stderr(), "failed to write response: %v\n", err)
+			r.ExitFn(2)
+			return
+		}
+	}
+	if result.Stderr != "" {
+		if _, err := io.WriteString(r.stderr(), result.Stderr); err != nil {
+			r.logger().Error("failed to write stderr", "error", err)
+		}
+	}
+	r.ExitFn(result.ExitCode)
+}
+
+// errorResult calls the Error handler if available and returns its response
 // If no Error handler or it returns nil, uses default error handling
-// Default exit code is 2, except for Stop events which use 0 to avoid blocking Claude from stopping
-func (r *Runner) handleError(ctx context.Context, rawJSON string, err error) {
+// Default exit code is 2, except for Stop and SubagentStop events which use 0 to avoid blocking Claude from stopping.
+// eventName is empty if the event could not be identified.
+func (r *Runner) errorResult(ctx context.Context, eventName, rawJSON string, err error) Result {
+	r.logger().ErrorContext(ctx, "hook error", "event", eventName, "error", err)
+
 	if r.Error != nil {
 		if response := r.Error(ctx, rawJSON, err); response != nil {
 			// Use the custom response
-			if response.Output != "" {
-				fmt.Fprint(os.Stdout, response.Output)
-			}
-			r.ExitFn(response.ExitCode)
-			return
+			return rawResult(response)
 		}
 	}
 
 	// Default error handling
-	fmt.Fprintf(os.Stderr, "%v\n", err)
-
-	// Determine exit code based on event type
 	exitCode := 2 // Default for most errors
+	if eventName == "Stop" || eventName == "SubagentStop" {
+		exitCode = 0 // Don't block Claude from stopping
+	}
+	return Result{ExitCode: exitCode, Stderr: fmt.Sprintf("%v\n", err)}
+}
 
-	// Parse the event type from rawJSON to check if it's a Stop event
-	var eventData map[string]interface{}
-	if json.Unmarshal([]byte(rawJSON), &eventData) == nil {
-		if event, ok := eventData["hook_event_name"].(string); ok && event == "Stop" {
-			exitCode = 0 // Don't block Claude from stopping
-		}
+// loadTranscript reads the transcript at path for event enrichment
+// Errors are not fatal - the transcript is optional and the handler can still work without it,
+// so an empty (never nil) transcript is returned instead and the error is logged
+func loadTranscript(path string, log *slog.Logger) []TranscriptEntry {
+	if path == "" {
+		return []TranscriptEntry{}
+	}
+	transcript, err := readTranscript(path, log)
+	if err != nil {
+		log.Warn("failed to read transcript", "path", path, "error", err)
+		return []TranscriptEntry{}
+	}
+	if transcript == nil {
+		return []TranscriptEntry{}
 	}
+	return transcript
+}
 
-	r.ExitFn(exitCode)
+// loadLastEntries reads the final n entries of the transcript at path, like loadTranscript
+func loadLastEntries(path string, n int, log *slog.Logger) []TranscriptEntry {
+	if path == "" {
+		return []TranscriptEntry{}
+	}
+	entries, err := readLastEntries(path, n, log)
+	if err != nil {
+		log.Warn("failed to read transcript", "path", path, "error", err)
+		return []TranscriptEntry{}
+	}
+	return entries
 }
 
 // readTranscript reads a JSONL transcript file and returns parsed entries
-func readTranscript(path string) ([]TranscriptEntry, error) {
-	file, err := os.Open(path)
+// Malformed lines are skipped and logged.
+func readTranscript(path string, log *slog.Logger) ([]TranscriptEntry, error) {
+	reader, err := OpenTranscript(path, 0)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open transcript file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	defer reader.Close()
 
 	var entries []TranscriptEntry
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-
-		// Skip empty lines
-		if line == "" {
-			continue
-		}
-
-		var entry TranscriptEntry
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+	for entry, err := range reader.Entries() {
+		var lineErr *TranscriptLineError
+		if errors.As(err, &lineErr) {
 			// Continue on error - some lines might be malformed
 			// but we want to read as much as possible
+			log.Warn("skipping malformed transcript line", "path", path, "line", lineErr.Line, "error", lineErr.Err)
 			continue
 		}
-
+		if err != nil {
+			return nil, err
+		}
 		entries = append(entries, entry)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading transcript file: %w", err)
+	// The transcript is read once, so an unterminated last line will not be completed
+	var lineErr *TranscriptLineError
+	if errors.As(reader.PendingLine(), &lineErr) {
+		log.Warn("skipping malformed transcript line", "path", path, "line", lineErr.Line, "error", lineErr.Err)
 	}
 
 	return entries, nil
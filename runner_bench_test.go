@@ -0,0 +1,139 @@
+package cchooks
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// benchmarkPayloads are realistic hook inputs, from a small PreToolUse event
+// to a PostToolUse event carrying the result of reading a large file
+func benchmarkPayloads(b *testing.B) map[string][]byte {
+	b.Helper()
+
+	var content strings.Builder
+	for content.Len() < 1<<20 {
+		content.WriteString("func handler(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {\n\treturn Approve()\n}\n")
+	}
+
+	payloads := map[string]interface{}{
+		"PreToolUse": map[string]interface{}{
+			"hook_event_name": "PreToolUse",
+			"session_id":      "abc123",
+			"transcript_path": "",
+			"cwd":             "/home/user/project",
+			"tool_name":       "Bash",
+			"tool_input":      map[string]interface{}{"command": "go test ./...", "description": "Run the tests"},
+		},
+		"PostToolUseRead1MB": map[string]interface{}{
+			"hook_event_name": "PostToolUse",
+			"session_id":      "abc123",
+			"transcript_path": "",
+			"cwd":             "/home/user/project",
+			"tool_name":       "Read",
+			"tool_input":      map[string]interface{}{"file_path": "/home/user/project/handler.go"},
+			"tool_response":   map[string]interface{}{"content": content.String()},
+		},
+		"UserPromptSubmit": map[string]interface{}{
+			"hook_event_name": "UserPromptSubmit",
+			"session_id":      "abc123",
+			"transcript_path": "",
+			"cwd":             "/home/user/project",
+			"prompt":          "Add a benchmark for the runner",
+		},
+	}
+
+	encoded := make(map[string][]byte, len(payloads))
+	for name, p := range payloads {
+		data, err := json.Marshal(p)
+		if err != nil {
+			b.Fatal(err)
+		}
+		encoded[name] = data
+	}
+	return encoded
+}
+
+func BenchmarkDecodeEvent(b *testing.B) {
+	for name, rawJSON := range benchmarkPayloads(b) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(rawJSON)))
+			b.ReportAllocs()
+			for b.Loop() {
+				eventName, err := peekEventName(rawJSON)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := decodeEvent(eventName, rawJSON, slog.New(slog.DiscardHandler)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDecodeEventMapRoundTrip measures the previous decoding strategy, which
+// unmarshalled into a map and marshalled it again before decoding the typed event
+func BenchmarkDecodeEventMapRoundTrip(b *testing.B) {
+	for name, rawJSON := range benchmarkPayloads(b) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(rawJSON)))
+			b.ReportAllocs()
+			for b.Loop() {
+				var rawEvent map[string]interface{}
+				if err := json.Unmarshal(rawJSON, &rawEvent); err != nil {
+					b.Fatal(err)
+				}
+				eventName, _ := rawEvent["hook_event_name"].(string)
+				eventData, err := json.Marshal(rawEvent)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := decodeEvent(eventName, eventData, slog.New(slog.DiscardHandler)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	runner := &Runner{
+		PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+			return DenyTool("not allowed")
+		},
+		PostToolUse: func(ctx context.Context, event *PostToolUseEvent) PostToolUseResponseInterface {
+			return WithAdditionalContext(Allow(), "checked")
+		},
+		UserPromptSubmit: func(ctx context.Context, event *UserPromptSubmitEvent) UserPromptSubmitResponseInterface {
+			return AddPromptContext("branch: main")
+		},
+	}
+
+	for name, rawJSON := range benchmarkPayloads(b) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(rawJSON)))
+			b.ReportAllocs()
+			ctx := context.Background()
+			for b.Loop() {
+				eventName, err := peekEventName(rawJSON)
+				if err != nil {
+					b.Fatal(err)
+				}
+				event, err := decodeEvent(eventName, rawJSON, slog.New(slog.DiscardHandler))
+				if err != nil {
+					b.Fatal(err)
+				}
+				response, err := runner.invoke(ctx, &Invocation{EventName: eventName, RawJSON: string(rawJSON), Event: event})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := encodeResponse(response); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
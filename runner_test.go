@@ -44,6 +44,175 @@ func TestRunner_Run(t *testing.T) {
   "decision": "block",
   "reason": "dangerous command"
 }
+`,
+		},
+		{
+			name:  "PreToolUse deny permission decision",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "rm -rf /"}}`,
+			runner: &Runner{
+				PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+					return DenyTool("dangerous command")
+				},
+			},
+			wantOutput: `{
+  "hookSpecificOutput": {
+    "hookEventName": "PreToolUse",
+    "permissionDecision": "deny",
+    "permissionDecisionReason": "dangerous command"
+  }
+}
+`,
+		},
+		{
+			name:  "PreToolUse ask permission decision",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "git push --force"}}`,
+			runner: &Runner{
+				PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+					return AskUser("force push requires confirmation")
+				},
+			},
+			wantOutput: `{
+  "hookSpecificOutput": {
+    "hookEventName": "PreToolUse",
+    "permissionDecision": "ask",
+    "permissionDecisionReason": "force push requires confirmation"
+  }
+}
+`,
+		},
+		{
+			name:  "PreToolUse updated input",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "sudo make deploy"}}`,
+			runner: &Runner{
+				PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+					bash, err := event.AsBash()
+					if err != nil {
+						return Error(err)
+					}
+					bash.Command = strings.TrimPrefix(bash.Command, "sudo ") + " --dry-run"
+					return UpdateInput(bash, "stripped sudo and forced dry run")
+				},
+			},
+			wantOutput: `{
+  "hookSpecificOutput": {
+    "hookEventName": "PreToolUse",
+    "permissionDecision": "ask",
+    "permissionDecisionReason": "stripped sudo and forced dry run",
+    "updatedInput": {
+      "command": "make deploy --dry-run"
+    }
+  }
+}
+`,
+		},
+		{
+			name:  "PreToolUse routed by tool name",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "MultiEdit", "tool_input": {}}`,
+			runner: (&Runner{
+				PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+					t.Error("catch-all should not be called when a route matches")
+					return nil
+				},
+			}).OnPreToolUse("Edit|Write|MultiEdit", func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+				return DenyTool("read-only workspace")
+			}),
+			wantOutput: `{
+  "hookSpecificOutput": {
+    "hookEventName": "PreToolUse",
+    "permissionDecision": "deny",
+    "permissionDecisionReason": "read-only workspace"
+  }
+}
+`,
+		},
+		{
+			name:  "middleware short-circuits after Raw",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`,
+			runner: (&Runner{
+				Raw: func(ctx context.Context, rawJSON string) *RawResponse {
+					return nil
+				},
+				PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+					t.Error("handler should not be called when middleware short-circuits")
+					return nil
+				},
+			}).Use(func(next HandlerFunc) HandlerFunc {
+				return func(ctx context.Context, inv *Invocation) interface{} {
+					return Block("blocked by middleware")
+				}
+			}),
+			wantOutput: `{
+  "decision": "block",
+  "reason": "blocked by middleware"
+}
+`,
+		},
+		{
+			name:  "PreToolUse nil response",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`,
+			runner: &Runner{
+				PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+					return nil
+				},
+			},
+			wantOutput: "",
+		},
+		{
+			name:  "PreToolUse handler timeout denies by default",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`,
+			runner: &Runner{
+				HandlerTimeout: 50 * time.Millisecond,
+				PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+					time.Sleep(time.Second)
+					return Approve()
+				},
+			},
+			wantOutput: `{
+  "hookSpecificOutput": {
+    "hookEventName": "PreToolUse",
+    "permissionDecision": "deny",
+    "permissionDecisionReason": "hook timed out"
+  }
+}
+`,
+		},
+		{
+			name:  "Stop handler timeout uses custom fallback",
+			input: `{"hook_event_name": "Stop", "session_id": "test", "stop_hook_active": false}`,
+			runner: &Runner{
+				HandlerTimeout: 50 * time.Millisecond,
+				TimeoutFallback: func(ctx context.Context, inv *Invocation) interface{} {
+					if inv.EventName == "Stop" {
+						return WithSystemMessage(Continue(), "stop check timed out")
+					}
+					return nil
+				},
+				Stop: func(ctx context.Context, event *StopEvent) StopResponseInterface {
+					<-ctx.Done()
+					time.Sleep(time.Second)
+					return BlockStop("too late")
+				},
+			},
+			wantOutput: `{
+  "systemMessage": "stop check timed out"
+}
+`,
+		},
+		{
+			name:  "handler finishing within the timeout",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`,
+			runner: &Runner{
+				HandlerTimeout: time.Second,
+				PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+					if _, ok := ctx.Deadline(); !ok {
+						t.Error("expected handler context to have a deadline")
+					}
+					return Approve()
+				},
+			},
+			wantOutput: `{
+  "decision": "approve"
+}
 `,
 		},
 		{
@@ -66,6 +235,39 @@ func TestRunner_Run(t *testing.T) {
 			},
 			wantOutput: "",
 		},
+		{
+			name:  "PostToolUse additional context and system message",
+			input: `{"hook_event_name": "PostToolUse", "session_id": "test", "tool_name": "Edit", "tool_input": {}, "tool_response": {}}`,
+			runner: &Runner{
+				PostToolUse: func(ctx context.Context, event *PostToolUseEvent) PostToolUseResponseInterface {
+					return WithSystemMessage(WithAdditionalContext(Allow(), "2 lint warnings"), "Lint ran")
+				},
+			},
+			wantOutput: `{
+  "systemMessage": "Lint ran",
+  "hookSpecificOutput": {
+    "hookEventName": "PostToolUse",
+    "additionalContext": "2 lint warnings"
+  }
+}
+`,
+		},
+		{
+			name:  "Notification hookEventName filled in",
+			input: `{"hook_event_name": "Notification", "session_id": "test", "notification_message": "hi"}`,
+			runner: &Runner{
+				Notification: func(ctx context.Context, event *NotificationEvent) NotificationResponseInterface {
+					return &NotificationResponse{CommonOutput: CommonOutput{SuppressOutput: true, HookSpecificOutput: &HookSpecificOutput{}}}
+				},
+			},
+			wantOutput: `{
+  "suppressOutput": true,
+  "hookSpecificOutput": {
+    "hookEventName": "Notification"
+  }
+}
+`,
+		},
 		{
 			name:  "Notification OK",
 			input: `{"hook_event_name": "Notification", "session_id": "test", "notification_message": "Task completed"}`,
@@ -151,8 +353,219 @@ func TestRunner_Run(t *testing.T) {
 `,
 		},
 		{
-			name:        "unknown event type",
+			name:  "SubagentStop block",
+			input: `{"hook_event_name": "SubagentStop", "session_id": "test", "stop_hook_active": true, "transcript_path": ""}`,
+			runner: &Runner{
+				SubagentStop: func(ctx context.Context, event *SubagentStopEvent) SubagentStopResponseInterface {
+					if event.Transcript() == nil {
+						t.Error("Transcript should not be nil")
+					}
+					return BlockSubagentStop("tests are still failing")
+				},
+			},
+			wantOutput: `{
+  "decision": "block",
+  "reason": "tests are still failing"
+}
+`,
+		},
+		{
+			name:  "SubagentStopOnce takes precedence when stop_hook_active is false",
+			input: `{"hook_event_name": "SubagentStop", "session_id": "test", "stop_hook_active": false, "transcript_path": ""}`,
+			runner: &Runner{
+				SubagentStopOnce: func(ctx context.Context, event *SubagentStopEvent) SubagentStopResponseInterface {
+					return BlockSubagentStop("from SubagentStopOnce")
+				},
+				SubagentStop: func(ctx context.Context, event *SubagentStopEvent) SubagentStopResponseInterface {
+					t.Error("SubagentStop should not be called when SubagentStopOnce is defined and stop_hook_active is false")
+					return nil
+				},
+			},
+			wantOutput: `{
+  "decision": "block",
+  "reason": "from SubagentStopOnce"
+}
+`,
+		},
+		{
+			name:  "SubagentStopOnce not called when stop_hook_active is true",
+			input: `{"hook_event_name": "SubagentStop", "session_id": "test", "stop_hook_active": true, "transcript_path": ""}`,
+			runner: &Runner{
+				SubagentStopOnce: func(ctx context.Context, event *SubagentStopEvent) SubagentStopResponseInterface {
+					t.Error("SubagentStopOnce should not be called when stop_hook_active is true")
+					return nil
+				},
+				SubagentStop: func(ctx context.Context, event *SubagentStopEvent) SubagentStopResponseInterface {
+					return ContinueSubagent()
+				},
+			},
+			wantOutput: "",
+		},
+		{
+			name:  "SubagentStop handler error exits 0",
+			input: `{"hook_event_name": "SubagentStop", "session_id": "test", "stop_hook_active": true, "transcript_path": ""}`,
+			runner: &Runner{
+				SubagentStop: func(ctx context.Context, event *SubagentStopEvent) SubagentStopResponseInterface {
+					return Error(errors.New("subagent handler error"))
+				},
+			},
+			wantOutput:  "",
+			wantErrCode: 0,
+		},
+		{
+			name:  "PreCompact stop",
+			input: `{"hook_event_name": "PreCompact", "session_id": "test", "transcript_path": "", "trigger": "manual", "custom_instructions": "keep it short"}`,
+			runner: &Runner{
+				PreCompact: func(ctx context.Context, event *PreCompactEvent) PreCompactResponseInterface {
+					if !event.IsManual() || event.CustomInstructions != "keep it short" {
+						t.Errorf("unexpected event: %+v", event)
+					}
+					return StopFromPreCompact("snapshot failed")
+				},
+			},
+			wantOutput: `{
+  "continue": false,
+  "stopReason": "snapshot failed"
+}
+`,
+		},
+		{
+			name:  "PreCompact allow",
+			input: `{"hook_event_name": "PreCompact", "session_id": "test", "transcript_path": "", "trigger": "auto", "custom_instructions": ""}`,
+			runner: &Runner{
+				PreCompact: func(ctx context.Context, event *PreCompactEvent) PreCompactResponseInterface {
+					if !event.IsAuto() {
+						t.Errorf("expected auto trigger, got %q", event.Trigger)
+					}
+					return AllowCompact()
+				},
+			},
+			wantOutput: "",
+		},
+		{
+			name:  "UserPromptSubmit allow",
+			input: `{"hook_event_name": "UserPromptSubmit", "session_id": "test", "transcript_path": "/tmp/t.jsonl", "cwd": "/work", "prompt": "fix ABC-123"}`,
+			runner: &Runner{
+				UserPromptSubmit: func(ctx context.Context, event *UserPromptSubmitEvent) UserPromptSubmitResponseInterface {
+					if event.Prompt != "fix ABC-123" || event.CWD != "/work" || event.TranscriptPath != "/tmp/t.jsonl" {
+						t.Errorf("unexpected event: %+v", event)
+					}
+					return AllowPrompt()
+				},
+			},
+			wantOutput: "",
+		},
+		{
+			name:  "UserPromptSubmit block",
+			input: `{"hook_event_name": "UserPromptSubmit", "session_id": "test", "prompt": "fix the bug"}`,
+			runner: &Runner{
+				UserPromptSubmit: func(ctx context.Context, event *UserPromptSubmitEvent) UserPromptSubmitResponseInterface {
+					return BlockPrompt("missing ticket reference")
+				},
+			},
+			wantOutput: `{
+  "decision": "block",
+  "reason": "missing ticket reference"
+}
+`,
+		},
+		{
+			name:  "UserPromptSubmit additional context",
+			input: `{"hook_event_name": "UserPromptSubmit", "session_id": "test", "prompt": "fix ABC-123"}`,
+			runner: &Runner{
+				UserPromptSubmit: func(ctx context.Context, event *UserPromptSubmitEvent) UserPromptSubmitResponseInterface {
+					return AddPromptContext("Project: cchooks")
+				},
+			},
+			wantOutput: `{
+  "hookSpecificOutput": {
+    "hookEventName": "UserPromptSubmit",
+    "additionalContext": "Project: cchooks"
+  }
+}
+`,
+		},
+		{
+			name:        "UserPromptSubmit without handler",
+			input:       `{"hook_event_name": "UserPromptSubmit", "session_id": "test", "prompt": "hello"}`,
+			runner:      &Runner{},
+			wantOutput:  "",
+			wantErrCode: 0,
+		},
+		{
+			name:  "SessionStart additional context",
+			input: `{"hook_event_name": "SessionStart", "session_id": "test", "transcript_path": "/tmp/t.jsonl", "cwd": "/work", "source": "resume"}`,
+			runner: &Runner{
+				SessionStart: func(ctx context.Context, event *SessionStartEvent) SessionStartResponseInterface {
+					if event.Source != SessionStartSourceResume || event.CWD != "/work" {
+						t.Errorf("unexpected event: %+v", event)
+					}
+					return AddSessionContext("Open tickets: ABC-1")
+				},
+			},
+			wantOutput: `{
+  "hookSpecificOutput": {
+    "hookEventName": "SessionStart",
+    "additionalContext": "Open tickets: ABC-1"
+  }
+}
+`,
+		},
+		{
+			name:  "SessionEnd",
+			input: `{"hook_event_name": "SessionEnd", "session_id": "test", "transcript_path": "/tmp/t.jsonl", "cwd": "/work", "reason": "logout"}`,
+			runner: &Runner{
+				SessionEnd: func(ctx context.Context, event *SessionEndEvent) SessionEndResponseInterface {
+					if event.Reason != SessionEndReasonLogout {
+						t.Errorf("Reason = %q, want %q", event.Reason, SessionEndReasonLogout)
+					}
+					return EndSession()
+				},
+			},
+			wantOutput: "",
+		},
+		{
+			name:       "unknown event type passes through",
+			input:      `{"hook_event_name": "Unknown", "session_id": "test"}`,
+			runner:     &Runner{},
+			wantOutput: "",
+		},
+		{
+			name:        "unknown event type with error policy",
 			input:       `{"hook_event_name": "Unknown", "session_id": "test"}`,
+			runner:      &Runner{UnknownEvents: UnknownEventError},
+			wantErrCode: 2,
+		},
+		{
+			name:  "unknown event type with Unknown handler",
+			input: `{"hook_event_name": "TaskComplete", "session_id": "test", "task": {"id": 7}}`,
+			runner: &Runner{
+				Unknown: func(ctx context.Context, event *GenericEvent) *RawResponse {
+					if event.HookEventName != "TaskComplete" || event.SessionID != "test" {
+						t.Errorf("unexpected event: %+v", event.HookInput)
+					}
+					if id, ok := event.Field("task.id"); !ok || id != float64(7) {
+						t.Errorf("Field(task.id) = %v, %v", id, ok)
+					}
+					return &RawResponse{Output: `{"systemMessage": "task done"}`}
+				},
+			},
+			wantOutput: `{"systemMessage": "task done"}`,
+		},
+		{
+			name:  "unknown event type with nil Unknown response",
+			input: `{"hook_event_name": "TaskComplete", "session_id": "test"}`,
+			runner: &Runner{
+				UnknownEvents: UnknownEventError,
+				Unknown: func(ctx context.Context, event *GenericEvent) *RawResponse {
+					return nil
+				},
+			},
+			wantOutput: "",
+		},
+		{
+			name:        "empty event name",
+			input:       `{"hook_event_name": "", "session_id": "test"}`,
 			runner:      &Runner{},
 			wantErrCode: 2,
 		},
@@ -237,7 +650,7 @@ func TestRunner_Run(t *testing.T) {
 func TestEventParsing(t *testing.T) {
 	t.Run("PreToolUseEvent parsing", func(t *testing.T) {
 		event := &PreToolUseEvent{
-			SessionID: "test",
+			HookInput: HookInput{SessionID: "test"},
 			ToolName:  "Bash",
 			ToolInput: json.RawMessage(`{"command": "ls", "timeout": 5000}`),
 		}
@@ -256,7 +669,7 @@ func TestEventParsing(t *testing.T) {
 
 	t.Run("PostToolUseEvent input parsing", func(t *testing.T) {
 		event := &PostToolUseEvent{
-			SessionID:    "test",
+			HookInput:    HookInput{SessionID: "test"},
 			ToolName:     "Edit",
 			ToolInput:    json.RawMessage(`{"file_path": "/test.txt", "old_string": "old", "new_string": "new"}`),
 			ToolResponse: json.RawMessage(`{"success": true}`),
@@ -280,6 +693,42 @@ func TestEventParsing(t *testing.T) {
 	})
 }
 
+func TestHookInputEnvelope(t *testing.T) {
+	input := `{"hook_event_name": "PreToolUse", "session_id": "abc", "transcript_path": "/home/u/.claude/t.jsonl", "cwd": "/work/project", "permission_mode": "plan", "tool_name": "Read", "tool_input": {"file_path": "src/main.go"}}`
+
+	var event PreToolUseEvent
+	if err := json.Unmarshal([]byte(input), &event); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+
+	want := HookInput{
+		SessionID:      "abc",
+		TranscriptPath: "/home/u/.claude/t.jsonl",
+		CWD:            "/work/project",
+		HookEventName:  "PreToolUse",
+		PermissionMode: PermissionModePlan,
+	}
+	if event.HookInput != want {
+		t.Errorf("HookInput = %+v, want %+v", event.HookInput, want)
+	}
+
+	read, err := event.AsRead()
+	if err != nil {
+		t.Fatalf("AsRead() error = %v", err)
+	}
+	if got := event.ResolvePath(read.FilePath); got != "/work/project/src/main.go" {
+		t.Errorf("ResolvePath(%q) = %q, want %q", read.FilePath, got, "/work/project/src/main.go")
+	}
+	if got := event.ResolvePath("/etc/../etc/hosts"); got != "/etc/hosts" {
+		t.Errorf("ResolvePath(absolute) = %q, want %q", got, "/etc/hosts")
+	}
+
+	noCWD := HookInput{}
+	if got := noCWD.ResolvePath("a/b"); got != "a/b" {
+		t.Errorf("ResolvePath without CWD = %q, want %q", got, "a/b")
+	}
+}
+
 func TestIsEmpty(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -301,6 +750,11 @@ func TestIsEmpty(t *testing.T) {
 			response: &PreToolUseResponse{Continue: func() *bool { b := false; return &b }()},
 			want:     false,
 		},
+		{
+			name:     "non-empty PreToolUseResponse with permission decision",
+			response: AllowTool(""),
+			want:     false,
+		},
 		{
 			name:     "empty PostToolUseResponse",
 			response: &PostToolUseResponse{},
@@ -331,6 +785,51 @@ func TestIsEmpty(t *testing.T) {
 			response: &StopResponse{Decision: "block"},
 			want:     false,
 		},
+		{
+			name:     "empty PreCompactResponse",
+			response: &PreCompactResponse{},
+			want:     true,
+		},
+		{
+			name:     "non-empty PreCompactResponse",
+			response: &PreCompactResponse{StopReason: "halt"},
+			want:     false,
+		},
+		{
+			name:     "empty SessionStartResponse",
+			response: &SessionStartResponse{},
+			want:     true,
+		},
+		{
+			name:     "non-empty SessionStartResponse",
+			response: &SessionStartResponse{CommonOutput: CommonOutput{HookSpecificOutput: &HookSpecificOutput{HookEventName: "SessionStart", AdditionalContext: "ctx"}}},
+			want:     false,
+		},
+		{
+			name:     "StopResponse with systemMessage",
+			response: &StopResponse{CommonOutput: CommonOutput{SystemMessage: "msg"}},
+			want:     false,
+		},
+		{
+			name:     "NotificationResponse with suppressOutput",
+			response: &NotificationResponse{CommonOutput: CommonOutput{SuppressOutput: true}},
+			want:     false,
+		},
+		{
+			name:     "empty SessionEndResponse",
+			response: &SessionEndResponse{},
+			want:     true,
+		},
+		{
+			name:     "empty UserPromptSubmitResponse",
+			response: &UserPromptSubmitResponse{},
+			want:     true,
+		},
+		{
+			name:     "non-empty UserPromptSubmitResponse with context",
+			response: &UserPromptSubmitResponse{CommonOutput: CommonOutput{HookSpecificOutput: &HookSpecificOutput{HookEventName: "UserPromptSubmit", AdditionalContext: "ctx"}}},
+			want:     false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -342,7 +841,7 @@ func TestIsEmpty(t *testing.T) {
 	}
 }
 
-func TestOutputResponse(t *testing.T) {
+func TestEncodeResponse(t *testing.T) {
 	tests := []struct {
 		name       string
 		response   interface{}
@@ -368,20 +867,10 @@ func TestOutputResponse(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Capture stdout
-			oldStdout := os.Stdout
-			r, w, _ := os.Pipe()
-			os.Stdout = w
-
-			err := outputResponse(tt.response)
-
-			w.Close()
-			os.Stdout = oldStdout
-
-			output, _ := io.ReadAll(r)
+			output, err := encodeResponse(tt.response)
 
 			if (err != nil) != tt.wantErr {
-				t.Errorf("outputResponse() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("encodeResponse() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
 			if string(output) != tt.wantOutput {
@@ -655,6 +1144,7 @@ func TestErrorHandler(t *testing.T) {
 			name:  "unknown event type",
 			input: `{"hook_event_name": "UnknownEvent", "session_id": "test"}`,
 			runner: &Runner{
+				UnknownEvents: UnknownEventError,
 				Error: func(ctx context.Context, rawJSON string, err error) *RawResponse {
 					expectedJSON := `{"hook_event_name": "UnknownEvent", "session_id": "test"}`
 					var expected, actual map[string]interface{}
@@ -669,6 +1159,78 @@ func TestErrorHandler(t *testing.T) {
 			},
 			wantErrString: "unknown event type: UnknownEvent",
 		},
+		{
+			name:  "typed handler decode error",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": 42}}`,
+			runner: HandlePre(&Runner{
+				Error: func(ctx context.Context, rawJSON string, err error) *RawResponse {
+					var decodeErr *DecodeError
+					if !errors.As(err, &decodeErr) || decodeErr.ToolName != "Bash" {
+						t.Errorf("Error handler got unexpected error: %v", err)
+					}
+					return nil
+				},
+			}, "Bash", func(ctx context.Context, event *PreToolUseEvent, bash *BashInput) PreToolUseResponseInterface {
+				t.Error("typed handler should not be called when decoding fails")
+				return nil
+			}),
+			wantErrString: "failed to decode tool_input of PreToolUse event for tool Bash",
+		},
+		{
+			name:  "middleware error",
+			input: `{"hook_event_name": "Notification", "session_id": "test", "notification_message": "hi"}`,
+			runner: (&Runner{
+				Error: func(ctx context.Context, rawJSON string, err error) *RawResponse {
+					if err == nil || err.Error() != "not on the allowlist" {
+						t.Errorf("Error handler got unexpected error: %v", err)
+					}
+					return nil
+				},
+			}).Use(func(next HandlerFunc) HandlerFunc {
+				return func(ctx context.Context, inv *Invocation) interface{} {
+					return Error(errors.New("not on the allowlist"))
+				}
+			}),
+			wantErrString: "not on the allowlist",
+		},
+		{
+			name:  "handler timeout reaches Error handler",
+			input: `{"hook_event_name": "UserPromptSubmit", "session_id": "test", "prompt": "hi"}`,
+			runner: &Runner{
+				HandlerTimeout: 50 * time.Millisecond,
+				UserPromptSubmit: func(ctx context.Context, event *UserPromptSubmitEvent) UserPromptSubmitResponseInterface {
+					time.Sleep(time.Second)
+					return AllowPrompt()
+				},
+				Error: func(ctx context.Context, rawJSON string, err error) *RawResponse {
+					var timeoutErr *TimeoutError
+					if !errors.As(err, &timeoutErr) || timeoutErr.EventName != "UserPromptSubmit" {
+						t.Errorf("Error handler got unexpected error: %v", err)
+					}
+					return &RawResponse{ExitCode: 2, Output: "timed out"}
+				},
+			},
+			wantCustomError: true,
+			wantErrCode:     2,
+			wantErrOutput:   "timed out",
+		},
+		{
+			name:  "panic in handler with timeout",
+			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`,
+			runner: &Runner{
+				HandlerTimeout: time.Second,
+				PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+					panic("handler panic")
+				},
+				Error: func(ctx context.Context, rawJSON string, err error) *RawResponse {
+					if err == nil || !strings.Contains(err.Error(), "panic: handler panic") {
+						t.Errorf("Error handler got unexpected error: %v", err)
+					}
+					return nil
+				},
+			},
+			wantErrString: "panic: handler panic",
+		},
 		{
 			name:  "panic in handler with error handler",
 			input: `{"hook_event_name": "PreToolUse", "session_id": "test", "tool_name": "Bash", "tool_input": {"command": "ls"}}`,
@@ -818,62 +1380,74 @@ func TestErrorHandler(t *testing.T) {
 }
 
 func TestStdinTimeout(t *testing.T) {
-	t.Run("stdin timeout", func(t *testing.T) {
-		// Set up a pipe that we won't write to, simulating no stdin input
-		oldStdin := os.Stdin
-		r, _, _ := os.Pipe()
-		os.Stdin = r
-		defer func() {
-			r.Close()
-			os.Stdin = oldStdin
-		}()
+	tests := []struct {
+		name         string
+		stdinTimeout time.Duration
+		want         time.Duration
+	}{
+		{name: "stdin timeout", want: time.Second},
+		{name: "configured stdin timeout", stdinTimeout: 300 * time.Millisecond, want: 300 * time.Millisecond},
+	}
 
-		// Set up stderr capture
-		oldStderr := os.Stderr
-		rErr, wErr, _ := os.Pipe()
-		os.Stderr = wErr
-		defer func() { os.Stderr = oldStderr }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Set up a pipe that we won't write to, simulating no stdin input
+			oldStdin := os.Stdin
+			r, _, _ := os.Pipe()
+			os.Stdin = r
+			defer func() {
+				r.Close()
+				os.Stdin = oldStdin
+			}()
 
-		// Capture exit code
-		var exitCode int
-		runner := &Runner{
-			ExitFn: func(code int) {
-				exitCode = code
-				panic("exit")
-			},
-		}
+			// Set up stderr capture
+			oldStderr := os.Stderr
+			rErr, wErr, _ := os.Pipe()
+			os.Stderr = wErr
+			defer func() { os.Stderr = oldStderr }()
 
-		// Run the test
-		start := time.Now()
-		func() {
-			defer func() {
-				if r := recover(); r != nil && r != "exit" {
-					panic(r)
-				}
+			// Capture exit code
+			var exitCode int
+			runner := &Runner{
+				StdinTimeout: tt.stdinTimeout,
+				ExitFn: func(code int) {
+					exitCode = code
+					panic("exit")
+				},
+			}
+
+			// Run the test
+			start := time.Now()
+			func() {
+				defer func() {
+					if r := recover(); r != nil && r != "exit" {
+						panic(r)
+					}
+				}()
+				runner.Run()
 			}()
-			runner.Run()
-		}()
-		elapsed := time.Since(start)
+			elapsed := time.Since(start)
 
-		// Close stderr write end
-		wErr.Close()
+			// Close stderr write end
+			wErr.Close()
 
-		// Read stderr
-		errOutput, _ := io.ReadAll(rErr)
+			// Read stderr
+			errOutput, _ := io.ReadAll(rErr)
 
-		// Check that it timed out within reasonable bounds (1s +/- 200ms)
-		if elapsed < 800*time.Millisecond || elapsed > 1200*time.Millisecond {
-			t.Errorf("expected timeout around 1s, got %v", elapsed)
-		}
+			// Check that it timed out within reasonable bounds (+/- 200ms)
+			if elapsed < tt.want-200*time.Millisecond || elapsed > tt.want+200*time.Millisecond {
+				t.Errorf("expected timeout around %v, got %v", tt.want, elapsed)
+			}
 
-		// Check exit code
-		if exitCode != 2 {
-			t.Errorf("exit code = %d, want 2", exitCode)
-		}
+			// Check exit code
+			if exitCode != 2 {
+				t.Errorf("exit code = %d, want 2", exitCode)
+			}
 
-		// Check error message
-		if !strings.Contains(string(errOutput), "timeout reading stdin") {
-			t.Errorf("stderr = %q, want to contain 'timeout reading stdin'", string(errOutput))
-		}
-	})
+			// Check error message
+			if !strings.Contains(string(errOutput), "timeout reading stdin") {
+				t.Errorf("stderr = %q, want to contain 'timeout reading stdin'", string(errOutput))
+			}
+		})
+	}
 }
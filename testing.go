@@ -3,17 +3,83 @@ package cchooks
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"reflect"
 )
 
 // TestRunner provides testing utilities for hook validation
 type TestRunner struct {
 	runner *Runner
+	input  HookInput
 }
 
 // NewTestRunner creates a new test runner
+// Test events are dispatched through the runner's routes and middleware, like in production
+// Events are populated with a test session ID, the current working directory and the default permission mode
 func NewTestRunner(runner *Runner) *TestRunner {
-	return &TestRunner{runner: runner}
+	cwd, _ := os.Getwd()
+	return &TestRunner{
+		runner: runner,
+		input: HookInput{
+			SessionID:      "test-session",
+			CWD:            cwd,
+			PermissionMode: PermissionModeDefault,
+		},
+	}
+}
+
+// WithHookInput sets the envelope fields used for every test event
+// HookEventName is always set to match the event being tested
+func (t *TestRunner) WithHookInput(input HookInput) *TestRunner {
+	t.input = input
+	return t
+}
+
+// hookInput returns the envelope for a test event of the given type
+func (t *TestRunner) hookInput(eventName string) HookInput {
+	input := t.input
+	input.HookEventName = eventName
+	return input
+}
+
+// invoke runs a test event through the runner's middleware chain and handlers
+// HandlerTimeout is applied, and the TimeoutFallback response is returned if it expires
+func (t *TestRunner) invoke(eventName string, event interface{}) interface{} {
+	rawJSON, err := json.Marshal(event)
+	if err != nil {
+		return Error(err)
+	}
+	if e, ok := event.(payloadEvent); ok && e.eventPayload().raw != nil {
+		// Keep the payload of events decoded from test input, such as unknown events
+		rawJSON = e.eventPayload().raw
+	} else {
+		bindRaw(event, rawJSON)
+	}
+
+	ctx := context.Background()
+	inv := &Invocation{
+		EventName: eventName,
+		RawJSON:   string(rawJSON),
+		Event:     event,
+	}
+	response, err := t.runner.invokeWithDeadline(ctx, inv)
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		// Return the fallback response the hook would output
+		response, err = t.runner.timeoutResponse(ctx, inv)
+	}
+	if err != nil {
+		return Error(err)
+	}
+	return response
+}
+
+// hasMiddleware reports whether the runner has middleware, which sees events that have
+// no handler and may answer them itself
+func (t *TestRunner) hasMiddleware() bool {
+	return len(t.runner.middleware) > 0
 }
 
 // TestPreToolUse tests a PreToolUse handler
@@ -24,16 +90,17 @@ func (t *TestRunner) TestPreToolUse(toolName string, toolInput interface{}) PreT
 	}
 
 	event := &PreToolUseEvent{
-		SessionID: "test-session",
+		HookInput: t.hookInput("PreToolUse"),
 		ToolName:  toolName,
 		ToolInput: inputJSON,
 	}
 
-	if t.runner.PreToolUse == nil {
+	if handler, _ := t.runner.preToolUseHandler(toolName); handler == nil && !t.hasMiddleware() {
 		return Error(fmt.Errorf("PreToolUse handler not set"))
 	}
 
-	return t.runner.PreToolUse(context.Background(), event)
+	resp, _ := t.invoke("PreToolUse", event).(PreToolUseResponseInterface)
+	return resp
 }
 
 // TestPostToolUse tests a PostToolUse handler
@@ -49,52 +116,170 @@ func (t *TestRunner) TestPostToolUse(toolName string, toolInput, toolResponse in
 	}
 
 	event := &PostToolUseEvent{
-		SessionID:    "test-session",
+		HookInput:    t.hookInput("PostToolUse"),
 		ToolName:     toolName,
 		ToolInput:    inputJSON,
 		ToolResponse: responseJSON,
 	}
 
-	if t.runner.PostToolUse == nil {
+	if handler, _ := t.runner.postToolUseHandler(toolName); handler == nil && !t.hasMiddleware() {
 		return Error(fmt.Errorf("PostToolUse handler not set"))
 	}
 
-	return t.runner.PostToolUse(context.Background(), event)
+	resp, _ := t.invoke("PostToolUse", event).(PostToolUseResponseInterface)
+	return resp
 }
 
 // TestNotification tests a Notification handler
 func (t *TestRunner) TestNotification(message string) NotificationResponseInterface {
 	event := &NotificationEvent{
-		SessionID: "test-session",
+		HookInput: t.hookInput("Notification"),
 		Message:   message,
 	}
 
-	if t.runner.Notification == nil {
+	if t.runner.Notification == nil && !t.hasMiddleware() {
 		return Error(fmt.Errorf("Notification handler not set"))
 	}
 
-	return t.runner.Notification(context.Background(), event)
+	resp, _ := t.invoke("Notification", event).(NotificationResponseInterface)
+	return resp
 }
 
 // TestStop tests a Stop handler
 func (t *TestRunner) TestStop(stopHookActive bool, transcript []TranscriptEntry) StopResponseInterface {
 	event := &StopEvent{
-		SessionID:      "test-session",
+		HookInput:      t.hookInput("Stop"),
 		StopHookActive: stopHookActive,
-		Transcript:     transcript,
-		TranscriptPath: "", // Empty path for test
+		transcript:     preloadedTranscript(transcript),
 	}
 
-	if t.runner.Stop == nil {
+	if handler, _ := t.runner.stopHandler(stopHookActive); handler == nil && !t.hasMiddleware() {
 		return Error(fmt.Errorf("Stop handler not set"))
 	}
 
-	return t.runner.Stop(context.Background(), event)
+	resp, _ := t.invoke("Stop", event).(StopResponseInterface)
+	return resp
+}
+
+// TestSubagentStop tests a SubagentStop handler
+func (t *TestRunner) TestSubagentStop(stopHookActive bool, transcript []TranscriptEntry) SubagentStopResponseInterface {
+	event := &SubagentStopEvent{
+		HookInput:      t.hookInput("SubagentStop"),
+		StopHookActive: stopHookActive,
+		transcript:     preloadedTranscript(transcript),
+	}
+
+	if handler, _ := t.runner.subagentStopHandler(stopHookActive); handler == nil && !t.hasMiddleware() {
+		return Error(fmt.Errorf("SubagentStop handler not set"))
+	}
+
+	resp, _ := t.invoke("SubagentStop", event).(SubagentStopResponseInterface)
+	return resp
+}
+
+// TestPreCompact tests a PreCompact handler
+func (t *TestRunner) TestPreCompact(trigger string, customInstructions string, transcript []TranscriptEntry) PreCompactResponseInterface {
+	event := &PreCompactEvent{
+		HookInput:          t.hookInput("PreCompact"),
+		Trigger:            trigger,
+		CustomInstructions: customInstructions,
+		transcript:         preloadedTranscript(transcript),
+	}
+
+	if t.runner.PreCompact == nil && !t.hasMiddleware() {
+		return Error(fmt.Errorf("PreCompact handler not set"))
+	}
+
+	resp, _ := t.invoke("PreCompact", event).(PreCompactResponseInterface)
+	return resp
+}
+
+// TestUserPromptSubmit tests a UserPromptSubmit handler
+func (t *TestRunner) TestUserPromptSubmit(prompt string) UserPromptSubmitResponseInterface {
+	event := &UserPromptSubmitEvent{
+		HookInput: t.hookInput("UserPromptSubmit"),
+		Prompt:    prompt,
+	}
+
+	if t.runner.UserPromptSubmit == nil && !t.hasMiddleware() {
+		return Error(fmt.Errorf("UserPromptSubmit handler not set"))
+	}
+
+	resp, _ := t.invoke("UserPromptSubmit", event).(UserPromptSubmitResponseInterface)
+	return resp
+}
+
+// TestSessionStart tests a SessionStart handler
+func (t *TestRunner) TestSessionStart(source string) SessionStartResponseInterface {
+	event := &SessionStartEvent{
+		HookInput: t.hookInput("SessionStart"),
+		Source:    source,
+	}
+
+	if t.runner.SessionStart == nil && !t.hasMiddleware() {
+		return Error(fmt.Errorf("SessionStart handler not set"))
+	}
+
+	resp, _ := t.invoke("SessionStart", event).(SessionStartResponseInterface)
+	return resp
+}
+
+// TestSessionEnd tests a SessionEnd handler
+func (t *TestRunner) TestSessionEnd(reason string) SessionEndResponseInterface {
+	event := &SessionEndEvent{
+		HookInput: t.hookInput("SessionEnd"),
+		Reason:    reason,
+	}
+
+	if t.runner.SessionEnd == nil && !t.hasMiddleware() {
+		return Error(fmt.Errorf("SessionEnd handler not set"))
+	}
+
+	resp, _ := t.invoke("SessionEnd", event).(SessionEndResponseInterface)
+	return resp
+}
+
+// TestUnknown tests the handling of an event type the SDK does not recognise
+// fields are added to the payload alongside the test envelope. The Unknown handler's
+// response is returned; if the event is rejected by the UnknownEvents policy, the error is returned.
+func (t *TestRunner) TestUnknown(eventName string, fields map[string]interface{}) (*RawResponse, error) {
+	input := t.hookInput(eventName)
+	envelope, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(envelope, &data); err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		data[name] = value
+	}
+	rawJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &GenericEvent{}
+	if err := json.Unmarshal(rawJSON, event); err != nil {
+		return nil, err
+	}
+	bindRaw(event, rawJSON)
+
+	switch resp := t.invoke(eventName, event).(type) {
+	case *RawResponse:
+		return resp, nil
+	case *ErrorResponse:
+		return nil, resp.Error
+	default:
+		return nil, nil
+	}
 }
 
 // Test assertion helpers
 
 // AssertPreToolUseApproves asserts that a PreToolUse handler approves
+// Both the legacy "approve" decision and permissionDecision "allow" are accepted
 func (t *TestRunner) AssertPreToolUseApproves(toolName string, toolInput interface{}) error {
 	resp := t.TestPreToolUse(toolName, toolInput)
 	if errResp, ok := resp.(*ErrorResponse); ok {
@@ -104,13 +289,14 @@ func (t *TestRunner) AssertPreToolUseApproves(toolName string, toolInput interfa
 	if !ok {
 		return fmt.Errorf("unexpected response type: %T", resp)
 	}
-	if preResp.Decision != PreToolUseApprove {
-		return fmt.Errorf("expected approve, got %s", preResp.Decision)
+	if decision := preResp.PermissionDecision(); decision != PermissionDecisionAllow {
+		return fmt.Errorf("expected approve, got %s", describeDecision(decision))
 	}
 	return nil
 }
 
 // AssertPreToolUseBlocks asserts that a PreToolUse handler blocks
+// Both the legacy "block" decision and permissionDecision "deny" are accepted
 func (t *TestRunner) AssertPreToolUseBlocks(toolName string, toolInput interface{}) error {
 	resp := t.TestPreToolUse(toolName, toolInput)
 	if errResp, ok := resp.(*ErrorResponse); ok {
@@ -120,8 +306,8 @@ func (t *TestRunner) AssertPreToolUseBlocks(toolName string, toolInput interface
 	if !ok {
 		return fmt.Errorf("unexpected response type: %T", resp)
 	}
-	if preResp.Decision != PreToolUseBlock {
-		return fmt.Errorf("expected block, got %s", preResp.Decision)
+	if decision := preResp.PermissionDecision(); decision != PermissionDecisionDeny {
+		return fmt.Errorf("expected block, got %s", describeDecision(decision))
 	}
 	return nil
 }
@@ -136,11 +322,60 @@ func (t *TestRunner) AssertPreToolUseBlocksWithReason(toolName string, toolInput
 	if !ok {
 		return fmt.Errorf("unexpected response type: %T", resp)
 	}
-	if preResp.Decision != PreToolUseBlock {
-		return fmt.Errorf("expected block, got %s", preResp.Decision)
+	if decision := preResp.PermissionDecision(); decision != PermissionDecisionDeny {
+		return fmt.Errorf("expected block, got %s", describeDecision(decision))
 	}
-	if preResp.Reason != expectedReason {
-		return fmt.Errorf("expected reason %q, got %q", expectedReason, preResp.Reason)
+	if reason := preResp.PermissionReason(); reason != expectedReason {
+		return fmt.Errorf("expected reason %q, got %q", expectedReason, reason)
+	}
+	return nil
+}
+
+// AssertPreToolUseAsks asserts that a PreToolUse handler asks the user for confirmation
+func (t *TestRunner) AssertPreToolUseAsks(toolName string, toolInput interface{}) error {
+	resp := t.TestPreToolUse(toolName, toolInput)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	preResp, ok := resp.(*PreToolUseResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	if decision := preResp.PermissionDecision(); decision != PermissionDecisionAsk {
+		return fmt.Errorf("expected ask, got %s", describeDecision(decision))
+	}
+	return nil
+}
+
+// AssertPreToolUseUpdatesInput asserts that a PreToolUse handler replaces the tool input with expectedInput
+// Inputs are compared by their JSON encoding
+func (t *TestRunner) AssertPreToolUseUpdatesInput(toolName string, toolInput interface{}, expectedInput interface{}) error {
+	resp := t.TestPreToolUse(toolName, toolInput)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	preResp, ok := resp.(*PreToolUseResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	updated := preResp.UpdatedInput()
+	if updated == nil {
+		return fmt.Errorf("expected updated input, got none")
+	}
+
+	expectedJSON, err := json.Marshal(expectedInput)
+	if err != nil {
+		return err
+	}
+	var want, got interface{}
+	if err := json.Unmarshal(expectedJSON, &want); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(updated, &got); err != nil {
+		return fmt.Errorf("failed to decode updated input: %w", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		return fmt.Errorf("expected updated input %s, got %s", expectedJSON, updated)
 	}
 	return nil
 }
@@ -259,3 +494,145 @@ func (t *TestRunner) AssertStopBlocks(stopHookActive bool, transcript []Transcri
 	}
 	return nil
 }
+
+// AssertSubagentStopContinues asserts that a SubagentStop handler lets the sub-agent stop
+func (t *TestRunner) AssertSubagentStopContinues(stopHookActive bool, transcript []TranscriptEntry) error {
+	resp := t.TestSubagentStop(stopHookActive, transcript)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	stopResp, ok := resp.(*SubagentStopResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	if stopResp.Decision != "" {
+		return fmt.Errorf("expected continue (empty decision), got %s", stopResp.Decision)
+	}
+	return nil
+}
+
+// AssertSubagentStopBlocks asserts that a SubagentStop handler blocks the sub-agent from stopping
+func (t *TestRunner) AssertSubagentStopBlocks(stopHookActive bool, transcript []TranscriptEntry) error {
+	resp := t.TestSubagentStop(stopHookActive, transcript)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	stopResp, ok := resp.(*SubagentStopResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	if stopResp.Decision != SubagentStopBlock {
+		return fmt.Errorf("expected block, got %s", stopResp.Decision)
+	}
+	return nil
+}
+
+// AssertPreCompactAllows asserts that a PreCompact handler lets compaction proceed
+func (t *TestRunner) AssertPreCompactAllows(trigger string, customInstructions string, transcript []TranscriptEntry) error {
+	resp := t.TestPreCompact(trigger, customInstructions, transcript)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	compactResp, ok := resp.(*PreCompactResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	if compactResp.Continue != nil && !*compactResp.Continue {
+		return fmt.Errorf("expected compaction to proceed, got stop: %s", compactResp.StopReason)
+	}
+	return nil
+}
+
+// AssertUserPromptSubmitAllows asserts that a UserPromptSubmit handler lets the prompt through
+func (t *TestRunner) AssertUserPromptSubmitAllows(prompt string) error {
+	resp := t.TestUserPromptSubmit(prompt)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	promptResp, ok := resp.(*UserPromptSubmitResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	if promptResp.Decision != "" {
+		return fmt.Errorf("expected allow (empty decision), got %s", promptResp.Decision)
+	}
+	return nil
+}
+
+// AssertUserPromptSubmitBlocks asserts that a UserPromptSubmit handler blocks the prompt
+func (t *TestRunner) AssertUserPromptSubmitBlocks(prompt string) error {
+	resp := t.TestUserPromptSubmit(prompt)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	promptResp, ok := resp.(*UserPromptSubmitResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	if promptResp.Decision != UserPromptSubmitBlock {
+		return fmt.Errorf("expected block, got %s", promptResp.Decision)
+	}
+	return nil
+}
+
+// AssertUserPromptSubmitAddsContext asserts that a UserPromptSubmit handler injects the expected context
+func (t *TestRunner) AssertUserPromptSubmitAddsContext(prompt string, expectedContext string) error {
+	resp := t.TestUserPromptSubmit(prompt)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	promptResp, ok := resp.(*UserPromptSubmitResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	if promptResp.HookSpecificOutput == nil {
+		return fmt.Errorf("expected additional context %q, got none", expectedContext)
+	}
+	if promptResp.HookSpecificOutput.AdditionalContext != expectedContext {
+		return fmt.Errorf("expected additional context %q, got %q", expectedContext, promptResp.HookSpecificOutput.AdditionalContext)
+	}
+	return nil
+}
+
+// AssertSessionStartAddsContext asserts that a SessionStart handler loads the expected context
+func (t *TestRunner) AssertSessionStartAddsContext(source string, expectedContext string) error {
+	resp := t.TestSessionStart(source)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	startResp, ok := resp.(*SessionStartResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	if startResp.HookSpecificOutput == nil {
+		return fmt.Errorf("expected additional context %q, got none", expectedContext)
+	}
+	if startResp.HookSpecificOutput.AdditionalContext != expectedContext {
+		return fmt.Errorf("expected additional context %q, got %q", expectedContext, startResp.HookSpecificOutput.AdditionalContext)
+	}
+	return nil
+}
+
+// AssertSessionEndOK asserts that a SessionEnd handler returns an empty response
+func (t *TestRunner) AssertSessionEndOK(reason string) error {
+	resp := t.TestSessionEnd(reason)
+	if errResp, ok := resp.(*ErrorResponse); ok {
+		return errResp.Error
+	}
+	endResp, ok := resp.(*SessionEndResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", resp)
+	}
+	if endResp.Continue != nil || endResp.StopReason != "" {
+		return fmt.Errorf("expected empty response, got continue=%v stopReason=%s", endResp.Continue, endResp.StopReason)
+	}
+	return nil
+}
+
+// describeDecision formats a permission decision for assertion messages
+func describeDecision(decision string) string {
+	if decision == "" {
+		return "no decision"
+	}
+	return decision
+}
@@ -3,7 +3,10 @@ package cchooks
 import (
 	"context"
 	"errors"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTestRunner(t *testing.T) {
@@ -197,6 +200,20 @@ func TestTestRunner(t *testing.T) {
 			t.Errorf("expected handler not set error, got %v", resp4)
 		}
 	})
+
+	t.Run("handler timeout returns fallback", func(t *testing.T) {
+		runner := &Runner{
+			HandlerTimeout: 20 * time.Millisecond,
+			PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+				<-ctx.Done()
+				return Approve()
+			},
+		}
+
+		if err := NewTestRunner(runner).AssertPreToolUseBlocks("Bash", &BashInput{Command: "ls"}); err != nil {
+			t.Error(err)
+		}
+	})
 }
 
 func TestAssertionHelpers(t *testing.T) {
@@ -409,4 +426,225 @@ func TestAssertionHelpers(t *testing.T) {
 			t.Errorf("expected handler error, got %v", err)
 		}
 	})
+
+	t.Run("UserPromptSubmit assertions", func(t *testing.T) {
+		runner := &Runner{
+			UserPromptSubmit: func(ctx context.Context, event *UserPromptSubmitEvent) UserPromptSubmitResponseInterface {
+				if !strings.Contains(event.Prompt, "ABC-") {
+					return BlockPrompt("missing ticket reference")
+				}
+				return AddPromptContext("ticket: " + event.Prompt)
+			},
+		}
+		tr := NewTestRunner(runner)
+
+		if err := tr.AssertUserPromptSubmitBlocks("fix the bug"); err != nil {
+			t.Errorf("AssertUserPromptSubmitBlocks() error = %v", err)
+		}
+		if err := tr.AssertUserPromptSubmitAllows("ABC-1"); err != nil {
+			t.Errorf("AssertUserPromptSubmitAllows() error = %v", err)
+		}
+		if err := tr.AssertUserPromptSubmitAddsContext("ABC-1", "ticket: ABC-1"); err != nil {
+			t.Errorf("AssertUserPromptSubmitAddsContext() error = %v", err)
+		}
+
+		// Test failure cases
+		if err := tr.AssertUserPromptSubmitAllows("fix the bug"); err == nil {
+			t.Error("expected error for blocked prompt")
+		}
+		if err := tr.AssertUserPromptSubmitAddsContext("ABC-1", "wrong"); err == nil {
+			t.Error("expected error for wrong context")
+		}
+	})
+
+	t.Run("SubagentStop assertions", func(t *testing.T) {
+		runner := &Runner{
+			SubagentStop: func(ctx context.Context, event *SubagentStopEvent) SubagentStopResponseInterface {
+				if event.LastSubagentMessage() == nil {
+					return BlockSubagentStop("sub-agent produced no output")
+				}
+				return ContinueSubagent()
+			},
+		}
+		tr := NewTestRunner(runner)
+
+		if err := tr.AssertSubagentStopBlocks(false, []TranscriptEntry{}); err != nil {
+			t.Errorf("AssertSubagentStopBlocks() error = %v", err)
+		}
+
+		transcript := []TranscriptEntry{{UUID: "a1", IsSidechain: true, Type: "assistant"}}
+		if err := tr.AssertSubagentStopContinues(false, transcript); err != nil {
+			t.Errorf("AssertSubagentStopContinues() error = %v", err)
+		}
+		if err := tr.AssertSubagentStopBlocks(false, transcript); err == nil {
+			t.Error("expected error for non-block response")
+		}
+	})
+
+	t.Run("PreCompact assertions", func(t *testing.T) {
+		runner := &Runner{
+			PreCompact: func(ctx context.Context, event *PreCompactEvent) PreCompactResponseInterface {
+				if event.IsAuto() {
+					return StopFromPreCompact("auto compaction disabled")
+				}
+				return AllowCompact()
+			},
+		}
+		tr := NewTestRunner(runner)
+
+		if err := tr.AssertPreCompactAllows(PreCompactTriggerManual, "", nil); err != nil {
+			t.Errorf("AssertPreCompactAllows() error = %v", err)
+		}
+		if err := tr.AssertPreCompactAllows(PreCompactTriggerAuto, "", nil); err == nil {
+			t.Error("expected error for stopped compaction")
+		}
+	})
+
+	t.Run("Session lifecycle assertions", func(t *testing.T) {
+		runner := &Runner{
+			SessionStart: func(ctx context.Context, event *SessionStartEvent) SessionStartResponseInterface {
+				if event.Source == SessionStartSourceStartup {
+					return AddSessionContext("fresh session")
+				}
+				return ContinueSession()
+			},
+			SessionEnd: func(ctx context.Context, event *SessionEndEvent) SessionEndResponseInterface {
+				return EndSession()
+			},
+		}
+		tr := NewTestRunner(runner)
+
+		if err := tr.AssertSessionStartAddsContext(SessionStartSourceStartup, "fresh session"); err != nil {
+			t.Errorf("AssertSessionStartAddsContext() error = %v", err)
+		}
+		if err := tr.AssertSessionStartAddsContext(SessionStartSourceResume, "fresh session"); err == nil {
+			t.Error("expected error when no context is added")
+		}
+		if err := tr.AssertSessionEndOK(SessionEndReasonClear); err != nil {
+			t.Errorf("AssertSessionEndOK() error = %v", err)
+		}
+	})
+
+	t.Run("hook input envelope", func(t *testing.T) {
+		var got HookInput
+		runner := &Runner{
+			PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+				got = event.HookInput
+				return Approve()
+			},
+		}
+		tr := NewTestRunner(runner)
+
+		tr.TestPreToolUse("Bash", &BashInput{Command: "ls"})
+		cwd, _ := os.Getwd()
+		if got.SessionID != "test-session" || got.CWD != cwd || got.HookEventName != "PreToolUse" || got.PermissionMode != PermissionModeDefault {
+			t.Errorf("unexpected default envelope: %+v", got)
+		}
+
+		tr.WithHookInput(HookInput{SessionID: "custom", CWD: "/work", PermissionMode: PermissionModeAcceptEdits})
+		tr.TestPreToolUse("Bash", &BashInput{Command: "ls"})
+		want := HookInput{SessionID: "custom", CWD: "/work", HookEventName: "PreToolUse", PermissionMode: PermissionModeAcceptEdits}
+		if got != want {
+			t.Errorf("HookInput = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("permission decision assertions", func(t *testing.T) {
+		runner := &Runner{
+			PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+				bash, _ := event.AsBash()
+				switch {
+				case strings.HasPrefix(bash.Command, "rm"):
+					return DenyTool("no deletions")
+				case strings.HasPrefix(bash.Command, "sudo"):
+					return AskUser("sudo needs confirmation")
+				}
+				return AllowTool("")
+			},
+		}
+		tr := NewTestRunner(runner)
+
+		if err := tr.AssertPreToolUseApproves("Bash", &BashInput{Command: "ls"}); err != nil {
+			t.Errorf("AssertPreToolUseApproves() error = %v", err)
+		}
+		if err := tr.AssertPreToolUseBlocks("Bash", &BashInput{Command: "rm x"}); err != nil {
+			t.Errorf("AssertPreToolUseBlocks() error = %v", err)
+		}
+		if err := tr.AssertPreToolUseBlocksWithReason("Bash", &BashInput{Command: "rm x"}, "no deletions"); err != nil {
+			t.Errorf("AssertPreToolUseBlocksWithReason() error = %v", err)
+		}
+		if err := tr.AssertPreToolUseAsks("Bash", &BashInput{Command: "sudo ls"}); err != nil {
+			t.Errorf("AssertPreToolUseAsks() error = %v", err)
+		}
+		if err := tr.AssertPreToolUseAsks("Bash", &BashInput{Command: "ls"}); err == nil {
+			t.Error("expected error for non-ask response")
+		}
+	})
+
+	t.Run("AssertPreToolUseUpdatesInput", func(t *testing.T) {
+		runner := &Runner{
+			PreToolUse: func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+				edit, err := event.AsEdit()
+				if err != nil {
+					return Error(err)
+				}
+				if strings.HasPrefix(edit.FilePath, "/tmp/") {
+					edit.FilePath = "/work/" + strings.TrimPrefix(edit.FilePath, "/tmp/")
+					return UpdateInput(edit, "rewrote /tmp path")
+				}
+				return AllowTool("")
+			},
+		}
+		tr := NewTestRunner(runner)
+
+		input := &EditInput{FilePath: "/tmp/a.txt", OldString: "a", NewString: "b"}
+		want := &EditInput{FilePath: "/work/a.txt", OldString: "a", NewString: "b"}
+		if err := tr.AssertPreToolUseUpdatesInput("Edit", input, want); err != nil {
+			t.Errorf("AssertPreToolUseUpdatesInput() error = %v", err)
+		}
+
+		// Wrong expectation and unchanged input both fail
+		if err := tr.AssertPreToolUseUpdatesInput("Edit", input, input); err == nil {
+			t.Error("expected error for mismatched input")
+		}
+		if err := tr.AssertPreToolUseUpdatesInput("Edit", want, want); err == nil {
+			t.Error("expected error when input is not updated")
+		}
+	})
+}
+
+func TestTestUnknown(t *testing.T) {
+	t.Run("Unknown handler", func(t *testing.T) {
+		runner := &Runner{
+			Unknown: func(ctx context.Context, event *GenericEvent) *RawResponse {
+				if event.HookEventName != "TaskComplete" || event.SessionID != "test-session" {
+					t.Errorf("unexpected event: %+v", event.HookInput)
+				}
+				if _, ok := event.Extra()["task_id"]; !ok {
+					t.Errorf("Extra() = %v, want task_id", event.Extra())
+				}
+				return &RawResponse{ExitCode: 0, Output: "seen"}
+			},
+		}
+
+		resp, err := NewTestRunner(runner).TestUnknown("TaskComplete", map[string]interface{}{"task_id": 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || resp.Output != "seen" {
+			t.Errorf("resp = %+v", resp)
+		}
+	})
+
+	t.Run("policies", func(t *testing.T) {
+		resp, err := NewTestRunner(&Runner{}).TestUnknown("TaskComplete", nil)
+		if resp != nil || err != nil {
+			t.Errorf("pass through: resp = %+v, err = %v", resp, err)
+		}
+
+		_, err = NewTestRunner(&Runner{UnknownEvents: UnknownEventError}).TestUnknown("TaskComplete", nil)
+		if err == nil || err.Error() != "unknown event type: TaskComplete" {
+			t.Errorf("error policy: err = %v", err)
+		}
+	})
 }
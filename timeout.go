@@ -0,0 +1,107 @@
+package cchooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultStdinTimeout is how long a Runner waits for the event on stdin when StdinTimeout is not set
+const DefaultStdinTimeout = 1 * time.Second
+
+// TimeoutError is passed to the Error handler when an event is not handled within Runner.HandlerTimeout
+type TimeoutError struct {
+	EventName string
+	Timeout   time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s handler timed out after %s", e.EventName, e.Timeout)
+}
+
+// DefaultTimeoutFallback is the TimeoutFallback used when a Runner does not set one
+// It denies PreToolUse events, so a hung policy check never lets a tool run, and
+// allows every other event.
+func DefaultTimeoutFallback(ctx context.Context, inv *Invocation) interface{} {
+	if inv.EventName == "PreToolUse" {
+		return DenyTool("hook timed out")
+	}
+	return nil
+}
+
+func (r *Runner) stdinTimeout() time.Duration {
+	if r.StdinTimeout > 0 {
+		return r.StdinTimeout
+	}
+	return DefaultStdinTimeout
+}
+
+// invokeWithDeadline runs invoke with the Runner's HandlerTimeout applied
+// The handler keeps running in the background after a timeout, but its response is discarded.
+// Panics in the handler are re-raised in the caller.
+func (r *Runner) invokeWithDeadline(ctx context.Context, inv *Invocation) (interface{}, error) {
+	if r.HandlerTimeout <= 0 {
+		return r.invoke(ctx, inv)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.HandlerTimeout)
+	defer cancel()
+
+	type result struct {
+		response interface{}
+		err      error
+		panicked bool
+		panicVal interface{}
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- result{panicked: true, panicVal: p}
+			}
+		}()
+		response, err := r.invoke(ctx, inv)
+		done <- result{response: response, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.panicked {
+			panic(res.panicVal)
+		}
+		return res.response, res.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, &TimeoutError{EventName: inv.EventName, Timeout: r.HandlerTimeout}
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// handleTimeout reports a timeout to the Error handler and returns the fallback response
+// If the Error handler returns a RawResponse, it is returned as the response.
+func (r *Runner) handleTimeout(ctx context.Context, inv *Invocation, timeoutErr *TimeoutError) (interface{}, error) {
+	r.logger().WarnContext(ctx, "handler timed out", "event", inv.EventName, "timeout", timeoutErr.Timeout)
+	if r.Error != nil {
+		if response := r.Error(ctx, inv.RawJSON, timeoutErr); response != nil {
+			return response, nil
+		}
+	}
+	return r.timeoutResponse(ctx, inv)
+}
+
+// timeoutResponse returns the TimeoutFallback response for an invocation
+func (r *Runner) timeoutResponse(ctx context.Context, inv *Invocation) (interface{}, error) {
+	fallback := r.TimeoutFallback
+	if fallback == nil {
+		fallback = DefaultTimeoutFallback
+	}
+
+	response := fallback(ctx, inv)
+	if err := checkResponse(inv.EventName, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
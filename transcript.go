@@ -2,6 +2,8 @@ package cchooks
 
 import (
 	"encoding/json"
+	"log/slog"
+	"sync"
 	"time"
 )
 
@@ -26,7 +28,7 @@ type TranscriptEntry struct {
 // UserMessage represents a user message in the transcript
 type UserMessage struct {
 	Role    string          `json:"role"`
-	Content json.RawMessage `json:"content"` // Can be string or array of content blocks
+	Content json.RawMessage `json:"content"` // Can be string or array of content blocks; see Blocks
 }
 
 // AssistantMessage represents an assistant message in the transcript
@@ -35,7 +37,7 @@ type AssistantMessage struct {
 	Type         string          `json:"type"`
 	Role         string          `json:"role"`
 	Model        string          `json:"model"`
-	Content      json.RawMessage `json:"content"` // Array of content blocks
+	Content      json.RawMessage `json:"content"` // Array of content blocks; see Blocks
 	StopReason   *string         `json:"stop_reason"`
 	StopSequence *string         `json:"stop_sequence"`
 	Usage        Usage           `json:"usage"`
@@ -51,6 +53,9 @@ type Usage struct {
 }
 
 // ContentBlock represents a content block in messages
+//
+// Deprecated: use TranscriptEntry.ContentBlocks, UserMessage.Blocks or
+// AssistantMessage.Blocks, which decode each block into its own MessageBlock type.
 type ContentBlock struct {
 	Type      string          `json:"type"`
 	Text      string          `json:"text,omitempty"`
@@ -95,3 +100,109 @@ func (t *TranscriptEntry) IsUserMessage() bool {
 func (t *TranscriptEntry) IsAssistantMessage() bool {
 	return t.Type == "assistant"
 }
+
+// sidechainEntries returns the entries that belong to a sub-agent sidechain
+func sidechainEntries(entries []TranscriptEntry) []TranscriptEntry {
+	var result []TranscriptEntry
+	for _, entry := range entries {
+		if entry.IsSidechain {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// lastSidechainThread returns the sidechain thread that ends with the most recent sidechain entry.
+// The thread is reconstructed by following parentUuid links back to the root of the sidechain.
+func lastSidechainThread(entries []TranscriptEntry) []TranscriptEntry {
+	byUUID := make(map[string]int)
+	last := -1
+	for i, entry := range entries {
+		if !entry.IsSidechain {
+			continue
+		}
+		byUUID[entry.UUID] = i
+		last = i
+	}
+	if last == -1 {
+		return nil
+	}
+
+	var thread []TranscriptEntry
+	seen := make(map[string]bool)
+	for i, ok := last, true; ok; {
+		entry := entries[i]
+		if seen[entry.UUID] {
+			break // Guard against cycles in malformed transcripts
+		}
+		seen[entry.UUID] = true
+		thread = append(thread, entry)
+
+		if entry.ParentUUID == nil {
+			break
+		}
+		i, ok = byUUID[*entry.ParentUUID]
+	}
+
+	// Reverse into conversation order
+	for i, j := 0, len(thread)-1; i < j; i, j = i+1, j-1 {
+		thread[i], thread[j] = thread[j], thread[i]
+	}
+	return thread
+}
+
+// transcriptLoader loads an event's transcript from disk when a handler first asks for it
+// A nil loader has an empty transcript.
+type transcriptLoader struct {
+	path string
+	log  *slog.Logger
+
+	mu      sync.Mutex
+	loaded  bool
+	entries []TranscriptEntry
+}
+
+// newTranscriptLoader returns a loader for the transcript at path
+func newTranscriptLoader(path string, log *slog.Logger) *transcriptLoader {
+	return &transcriptLoader{path: path, log: log}
+}
+
+// preloadedTranscript returns a loader that already holds entries
+func preloadedTranscript(entries []TranscriptEntry) *transcriptLoader {
+	if entries == nil {
+		entries = []TranscriptEntry{}
+	}
+	return &transcriptLoader{loaded: true, entries: entries}
+}
+
+// all returns the whole transcript, reading the file on the first call
+func (l *transcriptLoader) all() []TranscriptEntry {
+	if l == nil {
+		return []TranscriptEntry{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.loaded {
+		l.entries = loadTranscript(l.path, l.log)
+		l.loaded = true
+	}
+	return l.entries
+}
+
+// last returns the final n entries of the transcript
+// If the whole transcript has not been loaded, only the end of the file is read.
+func (l *transcriptLoader) last(n int) []TranscriptEntry {
+	if l == nil || n <= 0 {
+		return []TranscriptEntry{}
+	}
+
+	l.mu.Lock()
+	loaded, entries := l.loaded, l.entries
+	l.mu.Unlock()
+
+	if loaded {
+		return entries[max(len(entries)-n, 0):]
+	}
+	return loadLastEntries(l.path, n, l.log)
+}
@@ -0,0 +1,150 @@
+package cchooks
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/brads3290/cchooks/internal/tools"
+)
+
+// Transcript is a session transcript, in file order
+type Transcript []TranscriptEntry
+
+// ToolCall is one tool invocation reconstructed from a transcript
+// The call comes from an assistant tool_use block and is paired with the user tool_result
+// block that answers it.
+type ToolCall struct {
+	ID    string
+	Name  string
+	Input json.RawMessage
+	// Result is the tool_result answering the call, or nil if the transcript has none yet
+	Result *ToolResultBlock
+	// StartedAt is the timestamp of the assistant entry that made the call
+	StartedAt time.Time
+	// FinishedAt is the timestamp of the entry carrying the result; it is zero without a result
+	FinishedAt time.Time
+	// IsSidechain reports whether the call was made by a sub-agent
+	IsSidechain bool
+}
+
+// ToolCalls returns every tool call in the transcript, in the order they were made
+// Results are matched to calls by tool use ID. Calls that are still running, or were
+// interrupted, have a nil Result.
+func (t Transcript) ToolCalls() []*ToolCall {
+	var calls []*ToolCall
+	byID := make(map[string]*ToolCall)
+
+	for i := range t {
+		entry := &t[i]
+		switch {
+		case entry.IsAssistantMessage():
+			for _, use := range entry.ToolUses() {
+				call := &ToolCall{
+					ID:          use.ID,
+					Name:        use.Name,
+					Input:       use.Input,
+					StartedAt:   entry.Timestamp,
+					IsSidechain: entry.IsSidechain,
+				}
+				calls = append(calls, call)
+				byID[use.ID] = call
+			}
+		case entry.IsUserMessage():
+			for _, result := range entry.ToolResults() {
+				call, ok := byID[result.ToolUseID]
+				if !ok || call.Result != nil {
+					continue
+				}
+				call.Result = result
+				call.FinishedAt = entry.Timestamp
+			}
+		}
+	}
+
+	return calls
+}
+
+// Completed reports whether the transcript contains the call's result
+func (c *ToolCall) Completed() bool {
+	return c.Result != nil
+}
+
+// IsError reports whether the tool reported an error
+func (c *ToolCall) IsError() bool {
+	return c.Result != nil && c.Result.IsError
+}
+
+// Duration returns the time between the call and its result
+// It is zero if the call has no result or either timestamp is missing.
+func (c *ToolCall) Duration() time.Duration {
+	if c.Result == nil || c.StartedAt.IsZero() || c.FinishedAt.IsZero() {
+		return 0
+	}
+	return c.FinishedAt.Sub(c.StartedAt)
+}
+
+// GetToolInput implements tools.EventWithToolInput for ToolCall.
+func (c *ToolCall) GetToolInput() json.RawMessage {
+	return c.Input
+}
+
+// IsMCPTool returns true if this is an MCP tool (has "mcp__" prefix).
+func (c *ToolCall) IsMCPTool() bool {
+	return strings.HasPrefix(c.Name, "mcp__")
+}
+
+// TypedInput parses the input with the parser for the tool's name
+// It returns one of the typed inputs, such as *BashInput or *EditInput, or an *MCPTool
+// for MCP tools. Tools without a typed input return nil and no error; Input still holds
+// the raw JSON. If the input cannot be parsed, the returned input is a nil interface.
+func (c *ToolCall) TypedInput() (interface{}, error) {
+	if c.IsMCPTool() {
+		return typedInput(tools.ParseMCPTool(c.Name, c))
+	}
+
+	switch c.Name {
+	case "Bash":
+		return typedInput(tools.ParseBash(c))
+	case "Edit":
+		return typedInput(tools.ParseEdit(c))
+	case "MultiEdit":
+		return typedInput(tools.ParseMultiEdit(c))
+	case "Write":
+		return typedInput(tools.ParseWrite(c))
+	case "Read":
+		return typedInput(tools.ParseRead(c))
+	case "Glob":
+		return typedInput(tools.ParseGlob(c))
+	case "Grep":
+		return typedInput(tools.ParseGrep(c))
+	case "LS":
+		return typedInput(tools.ParseLS(c))
+	case "TodoWrite":
+		return typedInput(tools.ParseTodoWrite(c))
+	case "TodoRead":
+		return typedInput(tools.ParseTodoRead(c))
+	case "NotebookRead":
+		return typedInput(tools.ParseNotebookRead(c))
+	case "NotebookEdit":
+		return typedInput(tools.ParseNotebookEdit(c))
+	case "WebFetch":
+		return typedInput(tools.ParseWebFetch(c))
+	case "WebSearch":
+		return typedInput(tools.ParseWebSearch(c))
+	case "Task":
+		return typedInput(tools.ParseTask(c))
+	case "ExitPlanMode":
+		return typedInput(tools.ParseExitPlanMode(c))
+	}
+	return nil, nil
+}
+
+// typedInput converts a parser's result to an interface, returning a nil interface
+// rather than a typed nil when parsing fails
+func typedInput[T any](input *T, err error) (interface{}, error) {
+	if err != nil || input == nil {
+		return nil, err
+	}
+	return input, nil
+}
@@ -0,0 +1,118 @@
+package cchooks
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTranscriptToolCalls(t *testing.T) {
+	at := func(seconds int) time.Time {
+		return time.Date(2025, 1, 10, 10, 0, seconds, 0, time.UTC)
+	}
+	entry := func(entryType string, seconds int, content string) TranscriptEntry {
+		return TranscriptEntry{
+			Type:      entryType,
+			Timestamp: at(seconds),
+			Message:   json.RawMessage(`{"role": "` + entryType + `", "content": ` + content + `}`),
+		}
+	}
+
+	sidechain := entry("assistant", 9, `[{"type": "tool_use", "id": "toolu_4", "name": "Grep", "input": {"pattern": "TODO"}}]`)
+	sidechain.IsSidechain = true
+
+	transcript := Transcript{
+		entry("user", 0, `"Fix the build"`),
+		entry("assistant", 1, `[
+			{"type": "text", "text": "Checking"},
+			{"type": "tool_use", "id": "toolu_1", "name": "Bash", "input": {"command": "go build ./..."}},
+			{"type": "tool_use", "id": "toolu_2", "name": "Read", "input": {"file_path": "/src/main.go"}}
+		]`),
+		entry("user", 4, `[
+			{"type": "tool_result", "tool_use_id": "toolu_2", "content": "package main"},
+			{"type": "tool_result", "tool_use_id": "toolu_1", "content": "undefined: foo", "is_error": true}
+		]`),
+		entry("assistant", 5, `[{"type": "tool_use", "id": "toolu_3", "name": "mcp__github__create_issue", "input": {"title": "Build"}}]`),
+		entry("user", 8, `[{"type": "tool_result", "tool_use_id": "toolu_3", "content": [{"type": "text", "text": "created"}]}]`),
+		sidechain,
+		// A result for an unknown call is ignored
+		entry("user", 10, `[{"type": "tool_result", "tool_use_id": "toolu_missing", "content": "?"}]`),
+	}
+
+	calls := transcript.ToolCalls()
+	if len(calls) != 4 {
+		t.Fatalf("ToolCalls() returned %d calls, want 4", len(calls))
+	}
+
+	bash := calls[0]
+	if bash.ID != "toolu_1" || bash.Name != "Bash" {
+		t.Errorf("calls[0] = %s %s, want toolu_1 Bash", bash.ID, bash.Name)
+	}
+	if !bash.Completed() || !bash.IsError() || bash.Result.Text() != "undefined: foo" {
+		t.Errorf("Bash call result = %+v", bash.Result)
+	}
+	if bash.Duration() != 3*time.Second {
+		t.Errorf("Bash Duration() = %v, want 3s", bash.Duration())
+	}
+	input, err := bash.TypedInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bashInput, ok := input.(*BashInput); !ok || bashInput.Command != "go build ./..." {
+		t.Errorf("Bash TypedInput() = %#v", input)
+	}
+
+	read := calls[1]
+	if read.IsError() || read.Result.Text() != "package main" || !read.StartedAt.Equal(at(1)) || !read.FinishedAt.Equal(at(4)) {
+		t.Errorf("Read call = %+v", read)
+	}
+	if input, _ := read.TypedInput(); input.(*ReadInput).FilePath != "/src/main.go" {
+		t.Errorf("Read TypedInput() = %#v", input)
+	}
+
+	mcp := calls[2]
+	if !mcp.IsMCPTool() || mcp.Result.Text() != "created" || mcp.Duration() != 3*time.Second {
+		t.Errorf("MCP call = %+v", mcp)
+	}
+	if input, err := mcp.TypedInput(); err != nil || input.(*MCPTool).ToolName != "create_issue" {
+		t.Errorf("MCP TypedInput() = %#v, %v", input, err)
+	}
+
+	pending := calls[3]
+	if pending.Completed() || pending.IsError() || pending.Duration() != 0 || !pending.FinishedAt.IsZero() {
+		t.Errorf("pending call = %+v", pending)
+	}
+	if !pending.IsSidechain {
+		t.Error("expected the sub-agent call to be marked as sidechain")
+	}
+}
+
+func TestToolCallTypedInputUnknownTool(t *testing.T) {
+	call := &ToolCall{Name: "SomeFutureTool", Input: json.RawMessage(`{"x": 1}`)}
+	input, err := call.TypedInput()
+	if input != nil || err != nil {
+		t.Errorf("TypedInput() = %#v, %v, want nil, nil", input, err)
+	}
+}
+
+func TestToolCallTypedInputError(t *testing.T) {
+	tests := []*ToolCall{
+		{Name: "mcp__x", Input: json.RawMessage(`{}`)},
+		{Name: "Bash", Input: json.RawMessage(`{"command": 5}`)},
+	}
+	for _, call := range tests {
+		input, err := call.TypedInput()
+		if err == nil {
+			t.Errorf("%s: expected an error", call.Name)
+		}
+		if input != nil {
+			t.Errorf("%s: TypedInput() = %#v, want a nil interface", call.Name, input)
+		}
+	}
+}
+
+func TestTranscriptToolCallsEmpty(t *testing.T) {
+	if calls := (Transcript{}).ToolCalls(); len(calls) != 0 {
+		t.Errorf("ToolCalls() = %v, want none", calls)
+	}
+}
@@ -0,0 +1,281 @@
+package cchooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MessageBlock is one block of a transcript message's content
+// It is one of *TextBlock, *ThinkingBlock, *ToolUseBlock, *ToolResultBlock, *ImageBlock,
+// or *UnknownBlock for block types the SDK does not recognise. Blocks marshal back to
+// the transcript's JSON form, including the "type" field.
+type MessageBlock interface {
+	// BlockType returns the block's "type" field
+	BlockType() string
+}
+
+// TextBlock is plain text written by the user or the assistant
+type TextBlock struct {
+	Text string `json:"text"`
+}
+
+// ThinkingBlock is the assistant's extended thinking
+type ThinkingBlock struct {
+	Thinking  string `json:"thinking"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// ToolUseBlock is a tool call made by the assistant
+type ToolUseBlock struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// ToolResultBlock is the result of a tool call, sent back in a user message
+// ToolUseID matches the ID of the ToolUseBlock it answers.
+type ToolResultBlock struct {
+	ToolUseID string         `json:"tool_use_id"`
+	Content   []MessageBlock `json:"content,omitempty"`
+	IsError   bool           `json:"is_error,omitempty"`
+}
+
+// ImageBlock is an image attached to a message or returned by a tool
+type ImageBlock struct {
+	Source ImageSource `json:"source"`
+}
+
+// ImageSource describes where an ImageBlock's data comes from
+type ImageSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// UnknownBlock is a content block of a type the SDK does not decode
+// It marshals to Raw, the block exactly as it appeared in the transcript.
+type UnknownBlock struct {
+	Type string          `json:"type"`
+	Raw  json.RawMessage `json:"-"`
+}
+
+func (b *TextBlock) BlockType() string       { return "text" }
+func (b *ThinkingBlock) BlockType() string   { return "thinking" }
+func (b *ToolUseBlock) BlockType() string    { return "tool_use" }
+func (b *ToolResultBlock) BlockType() string { return "tool_result" }
+func (b *ImageBlock) BlockType() string      { return "image" }
+func (b *UnknownBlock) BlockType() string    { return b.Type }
+
+// marshalBlock encodes a block's fields as a JSON object with the block's "type" first
+func marshalBlock(blockType string, fields interface{}) ([]byte, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"type":`)
+	typeJSON, _ := json.Marshal(blockType)
+	buf.Write(typeJSON)
+	if len(data) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.Write(data[1:])
+	return buf.Bytes(), nil
+}
+
+// The plain types drop the MarshalJSON methods so the fields can be encoded by marshalBlock
+type (
+	plainTextBlock       TextBlock
+	plainThinkingBlock   ThinkingBlock
+	plainToolUseBlock    ToolUseBlock
+	plainToolResultBlock ToolResultBlock
+	plainImageBlock      ImageBlock
+)
+
+func (b TextBlock) MarshalJSON() ([]byte, error) {
+	return marshalBlock("text", plainTextBlock(b))
+}
+
+func (b ThinkingBlock) MarshalJSON() ([]byte, error) {
+	return marshalBlock("thinking", plainThinkingBlock(b))
+}
+
+func (b ToolUseBlock) MarshalJSON() ([]byte, error) {
+	return marshalBlock("tool_use", plainToolUseBlock(b))
+}
+
+func (b ToolResultBlock) MarshalJSON() ([]byte, error) {
+	return marshalBlock("tool_result", plainToolResultBlock(b))
+}
+
+func (b ImageBlock) MarshalJSON() ([]byte, error) {
+	return marshalBlock("image", plainImageBlock(b))
+}
+
+// MarshalJSON returns the block as it appeared in the transcript
+func (b UnknownBlock) MarshalJSON() ([]byte, error) {
+	if len(b.Raw) > 0 {
+		return b.Raw, nil
+	}
+	return marshalBlock(b.Type, struct{}{})
+}
+
+// Text returns the text of the tool result's text blocks, joined by newlines
+func (b *ToolResultBlock) Text() string {
+	return joinText(b.Content)
+}
+
+// UnmarshalJSON decodes a tool_result block, whose content may be a string or an array of blocks
+func (b *ToolResultBlock) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ToolUseID string          `json:"tool_use_id"`
+		Content   json.RawMessage `json:"content"`
+		IsError   bool            `json:"is_error"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	content, err := decodeContent(raw.Content)
+	if err != nil {
+		return err
+	}
+	b.ToolUseID, b.Content, b.IsError = raw.ToolUseID, content, raw.IsError
+	return nil
+}
+
+// decodeContent decodes a message's content into typed blocks
+// Content is either a plain string, which becomes a single TextBlock, or an array of blocks.
+func decodeContent(raw json.RawMessage) ([]MessageBlock, error) {
+	raw = json.RawMessage(strings.TrimSpace(string(raw)))
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	if raw[0] == '"' {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, err
+		}
+		return []MessageBlock{&TextBlock{Text: text}}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("content is neither a string nor an array of blocks: %w", err)
+	}
+
+	blocks := make([]MessageBlock, 0, len(items))
+	for i, item := range items {
+		block, err := decodeBlock(item)
+		if err != nil {
+			return nil, fmt.Errorf("content block %d: %w", i, err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// decodeBlock decodes a single content block according to its type
+func decodeBlock(raw json.RawMessage) (MessageBlock, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+
+	var block MessageBlock
+	switch header.Type {
+	case "text":
+		block = &TextBlock{}
+	case "thinking":
+		block = &ThinkingBlock{}
+	case "tool_use":
+		block = &ToolUseBlock{}
+	case "tool_result":
+		block = &ToolResultBlock{}
+	case "image":
+		block = &ImageBlock{}
+	default:
+		return &UnknownBlock{Type: header.Type, Raw: raw}, nil
+	}
+
+	if err := json.Unmarshal(raw, block); err != nil {
+		return nil, fmt.Errorf("invalid %s block: %w", header.Type, err)
+	}
+	return block, nil
+}
+
+// joinText concatenates the text of the text blocks in blocks, separated by newlines
+func joinText(blocks []MessageBlock) string {
+	var texts []string
+	for _, block := range blocks {
+		if text, ok := block.(*TextBlock); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Blocks decodes the message content, which may be a plain string or an array of blocks
+func (m *UserMessage) Blocks() ([]MessageBlock, error) {
+	return decodeContent(m.Content)
+}
+
+// Blocks decodes the message content into typed blocks
+func (m *AssistantMessage) Blocks() ([]MessageBlock, error) {
+	return decodeContent(m.Content)
+}
+
+// ContentBlocks decodes the content of the entry's user or assistant message
+// Entries of other types have no content blocks.
+func (t *TranscriptEntry) ContentBlocks() ([]MessageBlock, error) {
+	if !t.IsUserMessage() && !t.IsAssistantMessage() {
+		return nil, nil
+	}
+
+	var msg struct {
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(t.Message, &msg); err != nil {
+		return nil, err
+	}
+	return decodeContent(msg.Content)
+}
+
+// Text returns the text of the entry's text blocks, joined by newlines
+// Thinking, tool calls and tool results are not included. Entries whose content cannot be
+// decoded have no text.
+func (t *TranscriptEntry) Text() string {
+	blocks, _ := t.ContentBlocks()
+	return joinText(blocks)
+}
+
+// ToolUses returns the tool calls made in the entry, in order
+func (t *TranscriptEntry) ToolUses() []*ToolUseBlock {
+	blocks, _ := t.ContentBlocks()
+	var uses []*ToolUseBlock
+	for _, block := range blocks {
+		if use, ok := block.(*ToolUseBlock); ok {
+			uses = append(uses, use)
+		}
+	}
+	return uses
+}
+
+// ToolResults returns the tool results carried by the entry, in order
+func (t *TranscriptEntry) ToolResults() []*ToolResultBlock {
+	blocks, _ := t.ContentBlocks()
+	var results []*ToolResultBlock
+	for _, block := range blocks {
+		if result, ok := block.(*ToolResultBlock); ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
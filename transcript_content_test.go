@@ -0,0 +1,188 @@
+package cchooks
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []MessageBlock
+		wantErr bool
+	}{
+		{
+			name:    "plain string",
+			content: `"Hello"`,
+			want:    []MessageBlock{&TextBlock{Text: "Hello"}},
+		},
+		{
+			name:    "missing",
+			content: ``,
+		},
+		{
+			name:    "null",
+			content: `null`,
+		},
+		{
+			name: "assistant blocks",
+			content: `[
+				{"type": "thinking", "thinking": "Let me look", "signature": "sig"},
+				{"type": "text", "text": "Listing files"},
+				{"type": "tool_use", "id": "toolu_1", "name": "Bash", "input": {"command": "ls"}}
+			]`,
+			want: []MessageBlock{
+				&ThinkingBlock{Thinking: "Let me look", Signature: "sig"},
+				&TextBlock{Text: "Listing files"},
+				&ToolUseBlock{ID: "toolu_1", Name: "Bash", Input: json.RawMessage(`{"command": "ls"}`)},
+			},
+		},
+		{
+			name:    "tool result with string content",
+			content: `[{"type": "tool_result", "tool_use_id": "toolu_1", "content": "a.go\nb.go"}]`,
+			want: []MessageBlock{
+				&ToolResultBlock{ToolUseID: "toolu_1", Content: []MessageBlock{&TextBlock{Text: "a.go\nb.go"}}},
+			},
+		},
+		{
+			name: "tool result with block content",
+			content: `[{"type": "tool_result", "tool_use_id": "toolu_2", "is_error": true, "content": [
+				{"type": "text", "text": "failed"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "iVBO"}}
+			]}]`,
+			want: []MessageBlock{
+				&ToolResultBlock{ToolUseID: "toolu_2", IsError: true, Content: []MessageBlock{
+					&TextBlock{Text: "failed"},
+					&ImageBlock{Source: ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBO"}},
+				}},
+			},
+		},
+		{
+			name:    "unknown block type",
+			content: `[{"type": "redacted_thinking", "data": "xyz"}]`,
+			want:    []MessageBlock{&UnknownBlock{Type: "redacted_thinking", Raw: json.RawMessage(`{"type": "redacted_thinking", "data": "xyz"}`)}},
+		},
+		{
+			name:    "neither string nor array",
+			content: `42`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid block",
+			content: `[{"type": "text", "text": 7}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeContent(json.RawMessage(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeContent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeContent() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscriptEntryContentHelpers(t *testing.T) {
+	assistant := TranscriptEntry{
+		Type: "assistant",
+		Message: json.RawMessage(`{"role": "assistant", "content": [
+			{"type": "text", "text": "Running the tests"},
+			{"type": "tool_use", "id": "toolu_1", "name": "Bash", "input": {"command": "go test"}},
+			{"type": "text", "text": "and reading the log"},
+			{"type": "tool_use", "id": "toolu_2", "name": "Read", "input": {"file_path": "/tmp/log"}}
+		]}`),
+	}
+	user := TranscriptEntry{
+		Type: "user",
+		Message: json.RawMessage(`{"role": "user", "content": [
+			{"type": "tool_result", "tool_use_id": "toolu_1", "content": "ok"},
+			{"type": "tool_result", "tool_use_id": "toolu_2", "content": [{"type": "text", "text": "line 1"}, {"type": "text", "text": "line 2"}]}
+		]}`),
+	}
+	prompt := TranscriptEntry{
+		Type:    "user",
+		Message: json.RawMessage(`{"role": "user", "content": "Fix the build"}`),
+	}
+	summary := TranscriptEntry{Type: "summary"}
+
+	if got, want := assistant.Text(), "Running the tests\nand reading the log"; got != want {
+		t.Errorf("assistant Text() = %q, want %q", got, want)
+	}
+	uses := assistant.ToolUses()
+	if len(uses) != 2 || uses[0].Name != "Bash" || uses[1].ID != "toolu_2" {
+		t.Errorf("ToolUses() = %+v", uses)
+	}
+	if got := assistant.ToolResults(); got != nil {
+		t.Errorf("assistant ToolResults() = %+v, want nil", got)
+	}
+
+	results := user.ToolResults()
+	if len(results) != 2 || results[0].ToolUseID != "toolu_1" || results[0].Text() != "ok" {
+		t.Fatalf("ToolResults() = %+v", results)
+	}
+	if got, want := results[1].Text(), "line 1\nline 2"; got != want {
+		t.Errorf("ToolResults()[1].Text() = %q, want %q", got, want)
+	}
+	if got := user.Text(); got != "" {
+		t.Errorf("tool result entry Text() = %q, want empty", got)
+	}
+
+	if got := prompt.Text(); got != "Fix the build" {
+		t.Errorf("prompt Text() = %q, want %q", got, "Fix the build")
+	}
+	msg, err := prompt.GetUserMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocks, err := msg.Blocks(); err != nil || len(blocks) != 1 || blocks[0].BlockType() != "text" {
+		t.Errorf("UserMessage.Blocks() = %v, %v", blocks, err)
+	}
+
+	if blocks, err := summary.ContentBlocks(); blocks != nil || err != nil {
+		t.Errorf("summary ContentBlocks() = %v, %v, want nil, nil", blocks, err)
+	}
+}
+
+func TestMessageBlockMarshalJSON(t *testing.T) {
+	blocks := []MessageBlock{
+		&ThinkingBlock{Thinking: "Let me look"},
+		&TextBlock{Text: "Listing files"},
+		&ToolUseBlock{ID: "toolu_1", Name: "Bash", Input: json.RawMessage(`{"command":"ls"}`)},
+		&ToolResultBlock{ToolUseID: "toolu_1", IsError: true, Content: []MessageBlock{&TextBlock{Text: "denied"}}},
+		&ImageBlock{Source: ImageSource{Type: "url", URL: "https://example.com/a.png"}},
+		&UnknownBlock{Type: "redacted_thinking", Raw: json.RawMessage(`{"type":"redacted_thinking","data":"xyz"}`)},
+	}
+
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"type":"thinking","thinking":"Let me look"},` +
+		`{"type":"text","text":"Listing files"},` +
+		`{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"ls"}},` +
+		`{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"denied"}],"is_error":true},` +
+		`{"type":"image","source":{"type":"url","url":"https://example.com/a.png"}},` +
+		`{"type":"redacted_thinking","data":"xyz"}]`
+	if string(data) != want {
+		t.Errorf("json.Marshal() =\n%s\nwant\n%s", data, want)
+	}
+
+	// Marshalled blocks decode back to the same blocks
+	decoded, err := decodeContent(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, blocks) {
+		t.Errorf("decodeContent() = %#v, want %#v", decoded, blocks)
+	}
+}
@@ -0,0 +1,240 @@
+package cchooks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+// TranscriptLineError reports a transcript line that could not be decoded
+// Reading continues with the next line.
+type TranscriptLineError struct {
+	// Line is the 1-based line number, counted from where the TranscriptReader started
+	Line int
+	// Offset is the byte offset of the start of the line in the transcript
+	Offset int64
+	Err    error
+}
+
+func (e *TranscriptLineError) Error() string {
+	return fmt.Sprintf("transcript line %d (offset %d): %v", e.Line, e.Offset, e.Err)
+}
+
+func (e *TranscriptLineError) Unwrap() error {
+	return e.Err
+}
+
+// TranscriptReader streams entries from a JSONL transcript
+// Lines of any length are supported. Offset reports how far the reader has got, so a
+// later reader can resume from there with OpenTranscript, for example to read only the
+// entries added since the previous hook invocation.
+type TranscriptReader struct {
+	reader *bufio.Reader
+	closer io.Closer
+	offset int64
+	line   int
+	// partial holds a final line that was still being written when it was read, and
+	// partialErr the error decoding it
+	partial    []byte
+	partialErr error
+}
+
+// NewTranscriptReader returns a TranscriptReader reading from r
+// offset is the byte offset in the transcript at which r is positioned; it is used
+// for Offset and in errors.
+func NewTranscriptReader(r io.Reader, offset int64) *TranscriptReader {
+	return &TranscriptReader{
+		reader: bufio.NewReader(r),
+		offset: offset,
+	}
+}
+
+// OpenTranscript opens the transcript at path and positions the reader at offset
+// Pass 0 to read the whole transcript, or the Offset of a previous reader to resume.
+// The caller must Close the reader.
+func OpenTranscript(path string, offset int64) (*TranscriptReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript file: %w", err)
+	}
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to seek transcript file: %w", err)
+		}
+	}
+
+	reader := NewTranscriptReader(file, offset)
+	reader.closer = file
+	return reader, nil
+}
+
+// Offset returns the byte offset just past the last complete line read
+// A final line that is not yet terminated by a newline and does not decode is assumed
+// to be still being written; Offset stays before it, so resuming from Offset reads it again.
+func (t *TranscriptReader) Offset() int64 {
+	return t.offset
+}
+
+// PendingLine returns a *TranscriptLineError for the unterminated last line that Entries
+// held back because it does not decode, or nil if there is none
+// Readers that will not iterate again, because the transcript is complete, should report
+// it like any other malformed line.
+func (t *TranscriptReader) PendingLine() error {
+	if t.partial == nil {
+		return nil
+	}
+	return &TranscriptLineError{Line: t.line + 1, Offset: t.offset, Err: t.partialErr}
+}
+
+// Close closes the transcript file if the reader was created by OpenTranscript
+func (t *TranscriptReader) Close() error {
+	if t.closer == nil {
+		return nil
+	}
+	return t.closer.Close()
+}
+
+// Entries returns an iterator over the remaining transcript entries
+// A line that cannot be decoded is yielded as a *TranscriptLineError with a zero entry,
+// and iteration continues. An error reading the transcript is yielded last. Empty
+// lines are skipped.
+//
+// Iteration ends at the end of the transcript. Iterating again continues from there,
+// so a reader can follow a transcript that is still being written; a partially written
+// last line is kept and completed by the next iteration. A reader that reads the
+// transcript only once should check PendingLine afterwards.
+//
+//	for entry, err := range reader.Entries() {
+//	    if err != nil {
+//	        log.Print(err)
+//	        continue
+//	    }
+//	    ...
+//	}
+func (t *TranscriptReader) Entries() iter.Seq2[TranscriptEntry, error] {
+	return func(yield func(TranscriptEntry, error) bool) {
+		for {
+			line, err := t.reader.ReadBytes('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				yield(TranscriptEntry{}, fmt.Errorf("error reading transcript file: %w", err))
+				return
+			}
+			if len(t.partial) > 0 {
+				line = append(t.partial, line...)
+				t.partial, t.partialErr = nil, nil
+			}
+			if len(line) == 0 {
+				return
+			}
+
+			complete := line[len(line)-1] == '\n'
+			start := t.offset
+			content := bytes.TrimSpace(line)
+			if len(content) == 0 {
+				t.offset += int64(len(line))
+				t.line++
+				continue
+			}
+
+			var entry TranscriptEntry
+			if decodeErr := json.Unmarshal(content, &entry); decodeErr != nil {
+				if !complete {
+					// A partially written last line; keep it for the next iteration
+					t.partial, t.partialErr = line, decodeErr
+					return
+				}
+				t.offset += int64(len(line))
+				t.line++
+				if !yield(TranscriptEntry{}, &TranscriptLineError{Line: t.line, Offset: start, Err: decodeErr}) {
+					return
+				}
+				continue
+			}
+
+			t.offset += int64(len(line))
+			t.line++
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
+
+// lastEntriesChunkSize is how much of the transcript readLastEntries reads at a time
+const lastEntriesChunkSize = 64 * 1024
+
+// readLastEntries returns the final n entries of the transcript at path, in order
+// The file is scanned backward from the end, so the cost depends on the size of the
+// entries returned rather than the whole transcript. Malformed lines are skipped and logged.
+func readLastEntries(path string, n int, log *slog.Logger) ([]TranscriptEntry, error) {
+	if n <= 0 {
+		return []TranscriptEntry{}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat transcript file: %w", err)
+	}
+
+	// Entries are collected newest first and reversed at the end
+	entries := []TranscriptEntry{}
+	add := func(line []byte, offset int64) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			return
+		}
+		var entry TranscriptEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			log.Warn("skipping malformed transcript line", "path", path, "offset", offset, "error", err)
+			return
+		}
+		entries = append(entries, entry)
+	}
+
+	// rest holds the start of the file's unread bytes up to pos, followed by a line
+	// whose beginning has not been reached yet
+	pos := info.Size()
+	var rest []byte
+	for pos > 0 && len(entries) < n {
+		// Read at least as much as the partial line so far, keeping long lines linear
+		size := min(max(lastEntriesChunkSize, int64(len(rest))), pos)
+		pos -= size
+
+		buf := make([]byte, size+int64(len(rest)))
+		if _, err := file.ReadAt(buf[:size], pos); err != nil {
+			return nil, fmt.Errorf("error reading transcript file: %w", err)
+		}
+		copy(buf[size:], rest)
+
+		for len(entries) < n {
+			i := bytes.LastIndexByte(buf, '\n')
+			if i < 0 {
+				break
+			}
+			add(buf[i+1:], pos+int64(i+1))
+			buf = buf[:i]
+		}
+		rest = buf
+	}
+	if len(entries) < n {
+		// The first line of the file
+		add(rest, 0)
+	}
+
+	slices.Reverse(entries)
+	return entries, nil
+}
@@ -0,0 +1,367 @@
+package cchooks
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func transcriptLine(uuid, content string) string {
+	return `{"uuid":"` + uuid + `","type":"user","message":{"role":"user","content":"` + content + `"}}`
+}
+
+func TestTranscriptReaderEntries(t *testing.T) {
+	huge := strings.Repeat("x", 1<<20)
+
+	tests := []struct {
+		name       string
+		input      string
+		wantUUIDs  []string
+		wantErrs   []int // line numbers of TranscriptLineErrors
+		wantOffset int64
+		// wantPending is the line number PendingLine reports, or 0 for none
+		wantPending int
+	}{
+		{
+			name:       "complete lines",
+			input:      transcriptLine("1", "a") + "\n" + transcriptLine("2", "b") + "\n",
+			wantUUIDs:  []string{"1", "2"},
+			wantOffset: int64(2*len(transcriptLine("1", "a")) + 2),
+		},
+		{
+			name:       "line longer than 64KB",
+			input:      transcriptLine("1", huge) + "\n" + transcriptLine("2", "b") + "\n",
+			wantUUIDs:  []string{"1", "2"},
+			wantOffset: int64(len(transcriptLine("1", huge)) + len(transcriptLine("2", "b")) + 2),
+		},
+		{
+			name:       "malformed line reported and skipped",
+			input:      transcriptLine("1", "a") + "\n{not json\n\n" + transcriptLine("4", "d") + "\n",
+			wantUUIDs:  []string{"1", "4"},
+			wantErrs:   []int{2},
+			wantOffset: int64(2*len(transcriptLine("1", "a")) + len("{not json\n\n") + 2),
+		},
+		{
+			name:       "complete last line without newline",
+			input:      transcriptLine("1", "a") + "\n" + transcriptLine("2", "b"),
+			wantUUIDs:  []string{"1", "2"},
+			wantOffset: int64(2*len(transcriptLine("1", "a")) + 1),
+		},
+		{
+			name:        "partial last line left unread",
+			input:       transcriptLine("1", "a") + "\n" + `{"uuid":"2","ty`,
+			wantUUIDs:   []string{"1"},
+			wantOffset:  int64(len(transcriptLine("1", "a")) + 1),
+			wantPending: 2,
+		},
+		{
+			name:       "empty",
+			input:      "",
+			wantOffset: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewTranscriptReader(strings.NewReader(tt.input), 0)
+
+			var uuids []string
+			var errLines []int
+			for entry, err := range reader.Entries() {
+				var lineErr *TranscriptLineError
+				if errors.As(err, &lineErr) {
+					errLines = append(errLines, lineErr.Line)
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				uuids = append(uuids, entry.UUID)
+			}
+
+			if strings.Join(uuids, ",") != strings.Join(tt.wantUUIDs, ",") {
+				t.Errorf("UUIDs = %v, want %v", uuids, tt.wantUUIDs)
+			}
+			if len(errLines) != len(tt.wantErrs) {
+				t.Fatalf("line errors = %v, want %v", errLines, tt.wantErrs)
+			}
+			for i := range errLines {
+				if errLines[i] != tt.wantErrs[i] {
+					t.Errorf("line errors = %v, want %v", errLines, tt.wantErrs)
+				}
+			}
+			if reader.Offset() != tt.wantOffset {
+				t.Errorf("Offset() = %d, want %d", reader.Offset(), tt.wantOffset)
+			}
+
+			var pending *TranscriptLineError
+			if errors.As(reader.PendingLine(), &pending) {
+				if pending.Line != tt.wantPending || pending.Offset != tt.wantOffset || pending.Err == nil {
+					t.Errorf("PendingLine() = %+v, want line %d at offset %d", pending, tt.wantPending, tt.wantOffset)
+				}
+			} else if tt.wantPending != 0 {
+				t.Errorf("PendingLine() = nil, want line %d", tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestTranscriptReaderLineErrorOffset(t *testing.T) {
+	first := transcriptLine("1", "a") + "\n"
+	reader := NewTranscriptReader(strings.NewReader(first+"oops\n"), 100)
+
+	var lineErr *TranscriptLineError
+	for _, err := range reader.Entries() {
+		if err != nil && !errors.As(err, &lineErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if lineErr == nil {
+		t.Fatal("expected a TranscriptLineError")
+	}
+	if lineErr.Line != 2 {
+		t.Errorf("Line = %d, want 2", lineErr.Line)
+	}
+	if want := int64(100 + len(first)); lineErr.Offset != want {
+		t.Errorf("Offset = %d, want %d", lineErr.Offset, want)
+	}
+	if !strings.Contains(lineErr.Error(), "transcript line 2") {
+		t.Errorf("Error() = %q", lineErr.Error())
+	}
+}
+
+func TestTranscriptReaderReadError(t *testing.T) {
+	readErr := errors.New("disk on fire")
+	reader := NewTranscriptReader(iotest.ErrReader(readErr), 0)
+
+	var errs []error
+	for _, err := range reader.Entries() {
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], readErr) {
+		t.Errorf("errors = %v, want one wrapping %v", errs, readErr)
+	}
+}
+
+func TestTranscriptReaderStopEarly(t *testing.T) {
+	input := transcriptLine("1", "a") + "\n" + transcriptLine("2", "b") + "\n"
+	reader := NewTranscriptReader(strings.NewReader(input), 0)
+
+	for range reader.Entries() {
+		break
+	}
+
+	if want := int64(len(transcriptLine("1", "a")) + 1); reader.Offset() != want {
+		t.Errorf("Offset() = %d, want %d", reader.Offset(), want)
+	}
+
+	// Iterating again continues where the first loop stopped
+	var uuids []string
+	for entry, err := range reader.Entries() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		uuids = append(uuids, entry.UUID)
+	}
+	if len(uuids) != 1 || uuids[0] != "2" {
+		t.Errorf("UUIDs = %v, want [2]", uuids)
+	}
+}
+
+func TestOpenTranscriptResume(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "transcript-reader-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "transcript.jsonl")
+	if err := os.WriteFile(path, []byte(transcriptLine("1", "a")+"\n"+`{"uuid":"2"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(offset int64) ([]string, int64) {
+		t.Helper()
+		reader, err := OpenTranscript(path, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+
+		var uuids []string
+		for entry, err := range reader.Entries() {
+			if err != nil {
+				t.Fatal(err)
+			}
+			uuids = append(uuids, entry.UUID)
+		}
+		return uuids, reader.Offset()
+	}
+
+	uuids, offset := read(0)
+	if len(uuids) != 1 || uuids[0] != "1" {
+		t.Fatalf("first read UUIDs = %v, want [1]", uuids)
+	}
+
+	// Finish the partial line and append another
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`,"type":"user"}` + "\n" + transcriptLine("3", "c") + "\n")
+	file.Close()
+
+	uuids, _ = read(offset)
+	if strings.Join(uuids, ",") != "2,3" {
+		t.Errorf("resumed UUIDs = %v, want [2 3]", uuids)
+	}
+}
+
+func TestOpenTranscriptMissing(t *testing.T) {
+	if _, err := OpenTranscript(filepath.Join(t.TempDir(), "missing.jsonl"), 0); err == nil {
+		t.Error("expected error for missing transcript")
+	}
+}
+
+func TestReadLastEntries(t *testing.T) {
+	huge := strings.Repeat("x", 200*1024)
+	var many strings.Builder
+	for i := range 5000 {
+		many.WriteString(transcriptLine(fmt.Sprint(i), "padding to cross chunk boundaries") + "\n")
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		n     int
+		want  []string
+	}{
+		{
+			name:  "last two",
+			input: transcriptLine("1", "a") + "\n" + transcriptLine("2", "b") + "\n" + transcriptLine("3", "c") + "\n",
+			n:     2,
+			want:  []string{"2", "3"},
+		},
+		{
+			name:  "more than available",
+			input: transcriptLine("1", "a") + "\n" + transcriptLine("2", "b") + "\n",
+			n:     5,
+			want:  []string{"1", "2"},
+		},
+		{
+			name:  "zero",
+			input: transcriptLine("1", "a") + "\n",
+			n:     0,
+		},
+		{
+			name:  "empty file",
+			input: "",
+			n:     3,
+		},
+		{
+			name:  "no trailing newline",
+			input: transcriptLine("1", "a") + "\n" + transcriptLine("2", "b"),
+			n:     1,
+			want:  []string{"2"},
+		},
+		{
+			name:  "blank and malformed lines skipped",
+			input: transcriptLine("1", "a") + "\n" + transcriptLine("2", "b") + "\n{not json\n\n",
+			n:     2,
+			want:  []string{"1", "2"},
+		},
+		{
+			name:  "lines longer than a chunk",
+			input: transcriptLine("1", huge) + "\n" + transcriptLine("2", huge) + "\n" + transcriptLine("3", huge) + "\n",
+			n:     2,
+			want:  []string{"2", "3"},
+		},
+		{
+			name:  "entries spanning many chunks",
+			input: many.String(),
+			n:     3,
+			want:  []string{"4997", "4998", "4999"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "transcript.jsonl")
+			if err := os.WriteFile(path, []byte(tt.input), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := readLastEntries(path, tt.n, slog.New(slog.DiscardHandler))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries == nil {
+				t.Fatal("entries should not be nil")
+			}
+
+			var uuids []string
+			for _, entry := range entries {
+				uuids = append(uuids, entry.UUID)
+			}
+			if strings.Join(uuids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("UUIDs = %v, want %v", uuids, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscriptReaderFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	if err := os.WriteFile(path, []byte(transcriptLine("u1", "a")+"\n"+`{"uuid":"u2","ty`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := OpenTranscript(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	read := func() []string {
+		t.Helper()
+		var uuids []string
+		for entry, err := range reader.Entries() {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			uuids = append(uuids, entry.UUID)
+		}
+		return uuids
+	}
+
+	if uuids := read(); strings.Join(uuids, ",") != "u1" {
+		t.Fatalf("first pass UUIDs = %v, want [u1]", uuids)
+	}
+	partialOffset := reader.Offset()
+
+	// Nothing new yet: the partial line is still held back
+	if uuids := read(); len(uuids) != 0 {
+		t.Fatalf("second pass UUIDs = %v, want none", uuids)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`pe":"user"}` + "\n" + transcriptLine("u3", "c") + "\n")
+	file.Close()
+
+	if uuids := read(); strings.Join(uuids, ",") != "u2,u3" {
+		t.Errorf("after append UUIDs = %v, want [u2 u3]", uuids)
+	}
+	if info, _ := os.Stat(path); reader.Offset() != info.Size() || reader.Offset() <= partialOffset {
+		t.Errorf("Offset() = %d, want %d", reader.Offset(), info.Size())
+	}
+}
@@ -1,10 +1,14 @@
 package cchooks
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -36,7 +40,7 @@ func TestTranscriptReading(t *testing.T) {
 	file.Close()
 
 	// Test readTranscript function
-	entries, err := readTranscript(transcriptPath)
+	entries, err := readTranscript(transcriptPath, slog.New(slog.DiscardHandler))
 	if err != nil {
 		t.Fatalf("readTranscript failed: %v", err)
 	}
@@ -104,8 +108,8 @@ func TestStopEventWithTranscript(t *testing.T) {
 	runner := &Runner{
 		Stop: func(ctx context.Context, event *StopEvent) StopResponseInterface {
 			// Verify transcript was loaded
-			if len(event.Transcript) != 1 {
-				t.Errorf("Expected 1 transcript entry, got %d", len(event.Transcript))
+			if len(event.Transcript()) != 1 {
+				t.Errorf("Expected 1 transcript entry, got %d", len(event.Transcript()))
 			}
 			if event.TranscriptPath != transcriptPath {
 				t.Errorf("TranscriptPath = %s, want %s", event.TranscriptPath, transcriptPath)
@@ -161,11 +165,11 @@ func TestStopEventWithMissingTranscript(t *testing.T) {
 	runner := &Runner{
 		Stop: func(ctx context.Context, event *StopEvent) StopResponseInterface {
 			// Verify transcript is empty array, not nil
-			if event.Transcript == nil {
+			if event.Transcript() == nil {
 				t.Error("Transcript should not be nil")
 			}
-			if len(event.Transcript) != 0 {
-				t.Errorf("Expected empty transcript, got %d entries", len(event.Transcript))
+			if len(event.Transcript()) != 0 {
+				t.Errorf("Expected empty transcript, got %d entries", len(event.Transcript()))
 			}
 			return Continue()
 		},
@@ -257,3 +261,200 @@ func TestTranscriptEntryMethods(t *testing.T) {
 		t.Error("Expected GetAssistantMessage to return nil for user entry")
 	}
 }
+
+func TestSubagentStopEventSidechain(t *testing.T) {
+	parent := func(uuid string) *string { return &uuid }
+	event := &SubagentStopEvent{
+		HookInput: HookInput{SessionID: "test"},
+		transcript: preloadedTranscript([]TranscriptEntry{
+			{UUID: "1", Type: "user"},
+			{UUID: "2", ParentUUID: parent("1"), Type: "assistant"},
+			// First sub-agent
+			{UUID: "a1", IsSidechain: true, Type: "user"},
+			{UUID: "a2", ParentUUID: parent("a1"), IsSidechain: true, Type: "assistant"},
+			{UUID: "3", ParentUUID: parent("2"), Type: "user"},
+			// Second sub-agent, interleaved with the main conversation
+			{UUID: "b1", IsSidechain: true, Type: "user"},
+			{UUID: "4", ParentUUID: parent("3"), Type: "assistant"},
+			{UUID: "b2", ParentUUID: parent("b1"), IsSidechain: true, Type: "assistant",
+				Message: json.RawMessage(`{"role": "assistant", "content": [{"type": "tool_use", "id": "toolu_b", "name": "Grep", "input": {"pattern": "TODO"}}]}`)},
+			{UUID: "b3", ParentUUID: parent("b2"), IsSidechain: true, Type: "user",
+				Message: json.RawMessage(`{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "toolu_b", "content": "main.go:3"}]}`)},
+			{UUID: "b4", ParentUUID: parent("b3"), IsSidechain: true, Type: "assistant"},
+		}),
+	}
+
+	if got := len(event.SidechainEntries()); got != 6 {
+		t.Errorf("SidechainEntries() returned %d entries, want 6", got)
+	}
+
+	thread := event.SubagentEntries()
+	var uuids []string
+	for _, entry := range thread {
+		uuids = append(uuids, entry.UUID)
+	}
+	if want := []string{"b1", "b2", "b3", "b4"}; !reflect.DeepEqual(uuids, want) {
+		t.Errorf("SubagentEntries() = %v, want %v", uuids, want)
+	}
+
+	calls := thread.ToolCalls()
+	if len(calls) != 1 || calls[0].Name != "Grep" || !calls[0].Completed() || !calls[0].IsSidechain {
+		t.Errorf("SubagentEntries().ToolCalls() = %+v", calls)
+	}
+
+	last := event.LastSubagentMessage()
+	if last == nil || last.UUID != "b4" {
+		t.Errorf("LastSubagentMessage() = %v, want entry b4", last)
+	}
+
+	// No sidechain entries
+	empty := &SubagentStopEvent{transcript: preloadedTranscript(event.Transcript()[:2])}
+	if thread := empty.SubagentEntries(); thread != nil {
+		t.Errorf("SubagentEntries() = %v, want nil", thread)
+	}
+	if last := empty.LastSubagentMessage(); last != nil {
+		t.Errorf("LastSubagentMessage() = %v, want nil", last)
+	}
+}
+
+func TestPreCompactEventTranscript(t *testing.T) {
+	tmpDir := t.TempDir()
+	transcriptPath := filepath.Join(tmpDir, "compact-transcript.jsonl")
+	line := `{"parentUuid":null,"uuid":"1","isSidechain":false,"userType":"external","cwd":"/test","sessionId":"test-session","version":"1.0.0","type":"user","message":{"role":"user","content":"Test message"},"timestamp":"2025-01-10T10:00:00Z"}` + "\n"
+	if err := os.WriteFile(transcriptPath, []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshotPath := filepath.Join(tmpDir, "snapshot.jsonl")
+	runner := &Runner{
+		PreCompact: func(ctx context.Context, event *PreCompactEvent) PreCompactResponseInterface {
+			if len(event.Transcript()) != 1 {
+				t.Errorf("Expected 1 transcript entry, got %d", len(event.Transcript()))
+			}
+			if err := event.SnapshotTranscript(snapshotPath); err != nil {
+				t.Errorf("SnapshotTranscript failed: %v", err)
+			}
+			return AllowCompact()
+		},
+	}
+
+	input := `{"hook_event_name": "PreCompact", "session_id": "test", "trigger": "auto", "transcript_path": "` + transcriptPath + `"}`
+
+	// Mock stdin
+	oldStdin := os.Stdin
+	r, w, _ := os.Pipe()
+	os.Stdin = r
+	w.Write([]byte(input))
+	w.Close()
+	defer func() { os.Stdin = oldStdin }()
+
+	// Mock exit
+	exitCode := -1
+	runner.ExitFn = func(code int) {
+		exitCode = code
+		panic("exit")
+	}
+
+	// Run
+	func() {
+		defer func() {
+			if r := recover(); r != nil && r != "exit" {
+				panic(r)
+			}
+		}()
+		runner.Run()
+	}()
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", exitCode)
+	}
+
+	snapshot, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+	if string(snapshot) != line {
+		t.Errorf("snapshot = %q, want %q", snapshot, line)
+	}
+
+	// Snapshot without a transcript path fails
+	event := &PreCompactEvent{}
+	if err := event.SnapshotTranscript(snapshotPath); err == nil {
+		t.Error("expected error when transcript path is empty")
+	}
+}
+
+func TestStopEventLazyTranscript(t *testing.T) {
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	writeLines := func(uuids ...string) {
+		t.Helper()
+		file, err := os.OpenFile(transcriptPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, uuid := range uuids {
+			file.WriteString(`{"uuid":"` + uuid + `","type":"user"}` + "\n")
+		}
+		file.Close()
+	}
+	uuids := func(entries []TranscriptEntry) []string {
+		result := []string{}
+		for _, entry := range entries {
+			result = append(result, entry.UUID)
+		}
+		return result
+	}
+
+	writeLines("1", "2", "3")
+	input := `{"hook_event_name": "Stop", "session_id": "test", "stop_hook_active": false, "transcript_path": "` + transcriptPath + `"}`
+
+	t.Run("not read unless used", func(t *testing.T) {
+		var buf bytes.Buffer
+		runner := &Runner{
+			Logger: slog.New(slog.NewJSONHandler(&buf, nil)),
+			Stop: func(ctx context.Context, event *StopEvent) StopResponseInterface {
+				return Continue()
+			},
+		}
+		missing := `{"hook_event_name": "Stop", "session_id": "test", "transcript_path": "/nonexistent/transcript.jsonl"}`
+		runner.execute(context.Background(), []byte(missing))
+
+		if strings.Contains(buf.String(), "failed to read transcript") {
+			t.Errorf("transcript was read although the handler did not use it: %s", buf.String())
+		}
+	})
+
+	t.Run("last entries then full transcript", func(t *testing.T) {
+		runner := &Runner{
+			Stop: func(ctx context.Context, event *StopEvent) StopResponseInterface {
+				if got := uuids(event.LastEntries(2)); !reflect.DeepEqual(got, []string{"2", "3"}) {
+					t.Errorf("LastEntries(2) = %v, want [2 3]", got)
+				}
+				if got := uuids(event.Transcript()); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+					t.Errorf("Transcript() = %v, want [1 2 3]", got)
+				}
+
+				// Once loaded, the transcript is cached and LastEntries uses it
+				writeLines("4")
+				if got := uuids(event.Transcript()); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+					t.Errorf("Transcript() after append = %v, want [1 2 3]", got)
+				}
+				if got := uuids(event.LastEntries(1)); !reflect.DeepEqual(got, []string{"3"}) {
+					t.Errorf("LastEntries(1) after load = %v, want [3]", got)
+				}
+				return Continue()
+			},
+		}
+		runner.execute(context.Background(), []byte(input))
+	})
+
+	t.Run("event without transcript", func(t *testing.T) {
+		event := &StopEvent{}
+		if got := event.Transcript(); got == nil || len(got) != 0 {
+			t.Errorf("Transcript() = %v, want empty", got)
+		}
+		if got := event.LastEntries(3); got == nil || len(got) != 0 {
+			t.Errorf("LastEntries(3) = %v, want empty", got)
+		}
+	})
+}
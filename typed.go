@@ -0,0 +1,72 @@
+package cchooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// DecodeError is returned to the Error handler when a typed handler registered with
+// HandlePre or HandlePost cannot decode the tool input or tool response
+type DecodeError struct {
+	EventName string // PreToolUse or PostToolUse
+	ToolName  string
+	Field     string // tool_input or tool_response
+	Err       error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s of %s event for tool %s: %v", e.Field, e.EventName, e.ToolName, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// HandlePre registers a PreToolUse handler that receives the tool input decoded as T
+// The matcher uses the same syntax as OnPreToolUse. If the input cannot be decoded,
+// the handler is not called and a *DecodeError is passed to the Runner's Error handler.
+//
+//	cchooks.HandlePre(runner, "Bash", func(ctx context.Context, event *cchooks.PreToolUseEvent, bash *cchooks.BashInput) cchooks.PreToolUseResponseInterface {
+//	    if strings.Contains(bash.Command, "rm -rf") {
+//	        return cchooks.DenyTool("dangerous command")
+//	    }
+//	    return cchooks.Approve()
+//	})
+func HandlePre[T ToolInput](r *Runner, matcher string, handler func(context.Context, *PreToolUseEvent, *T) PreToolUseResponseInterface) *Runner {
+	return r.OnPreToolUse(matcher, func(ctx context.Context, event *PreToolUseEvent) PreToolUseResponseInterface {
+		var input T
+		if err := decodeToolJSON(event.ToolInput, &input); err != nil {
+			return Error(&DecodeError{EventName: "PreToolUse", ToolName: event.ToolName, Field: "tool_input", Err: err})
+		}
+		return handler(ctx, event, &input)
+	})
+}
+
+// HandlePost registers a PostToolUse handler that receives the tool input decoded as T
+// and the tool response decoded as O
+// The matcher uses the same syntax as OnPostToolUse. If the input or response cannot be
+// decoded, the handler is not called and a *DecodeError is passed to the Runner's Error handler.
+func HandlePost[T ToolInput, O ToolOutput](r *Runner, matcher string, handler func(context.Context, *PostToolUseEvent, *T, *O) PostToolUseResponseInterface) *Runner {
+	return r.OnPostToolUse(matcher, func(ctx context.Context, event *PostToolUseEvent) PostToolUseResponseInterface {
+		var input T
+		if err := decodeToolJSON(event.ToolInput, &input); err != nil {
+			return Error(&DecodeError{EventName: "PostToolUse", ToolName: event.ToolName, Field: "tool_input", Err: err})
+		}
+
+		var output O
+		if err := decodeToolJSON(event.ToolResponse, &output); err != nil {
+			return Error(&DecodeError{EventName: "PostToolUse", ToolName: event.ToolName, Field: "tool_response", Err: err})
+		}
+
+		return handler(ctx, event, &input, &output)
+	})
+}
+
+// decodeToolJSON decodes a tool input or response, leaving v as the zero value if data is empty
+func decodeToolJSON(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,82 @@
+package cchooks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHandlePre(t *testing.T) {
+	runner := &Runner{}
+	HandlePre(runner, "Bash", func(ctx context.Context, event *PreToolUseEvent, bash *BashInput) PreToolUseResponseInterface {
+		if strings.HasPrefix(bash.Command, "sudo ") {
+			return DenyTool("no sudo")
+		}
+		return AllowTool("")
+	})
+	HandlePre(runner, "Edit|Write", func(ctx context.Context, event *PreToolUseEvent, write *WriteInput) PreToolUseResponseInterface {
+		if strings.HasPrefix(write.FilePath, "/etc/") {
+			return DenyTool("system file")
+		}
+		return AllowTool("")
+	})
+
+	tester := NewTestRunner(runner)
+
+	if err := tester.AssertPreToolUseBlocks("Bash", &BashInput{Command: "sudo ls"}); err != nil {
+		t.Error(err)
+	}
+	if err := tester.AssertPreToolUseApproves("Bash", &BashInput{Command: "ls"}); err != nil {
+		t.Error(err)
+	}
+	if err := tester.AssertPreToolUseBlocks("Write", &WriteInput{FilePath: "/etc/hosts"}); err != nil {
+		t.Error(err)
+	}
+
+	t.Run("decode error", func(t *testing.T) {
+		resp := tester.TestPreToolUse("Bash", map[string]int{"command": 1})
+		errResp, ok := resp.(*ErrorResponse)
+		if !ok {
+			t.Fatalf("expected ErrorResponse, got %T", resp)
+		}
+		var decodeErr *DecodeError
+		if !errors.As(errResp.Error, &decodeErr) {
+			t.Fatalf("expected *DecodeError, got %T", errResp.Error)
+		}
+		if decodeErr.EventName != "PreToolUse" || decodeErr.ToolName != "Bash" || decodeErr.Field != "tool_input" {
+			t.Errorf("unexpected DecodeError: %+v", decodeErr)
+		}
+	})
+}
+
+func TestHandlePost(t *testing.T) {
+	runner := &Runner{}
+	HandlePost(runner, "Bash", func(ctx context.Context, event *PostToolUseEvent, bash *BashInput, out *BashOutput) PostToolUseResponseInterface {
+		if out.ExitCode != 0 {
+			return PostBlock(bash.Command + " failed")
+		}
+		return Allow()
+	})
+
+	tester := NewTestRunner(runner)
+
+	if err := tester.AssertPostToolUseBlocks("Bash", &BashInput{Command: "make"}, &BashOutput{ExitCode: 2}); err != nil {
+		t.Error(err)
+	}
+	if err := tester.AssertPostToolUseAllows("Bash", &BashInput{Command: "make"}, &BashOutput{}); err != nil {
+		t.Error(err)
+	}
+
+	t.Run("response decode error", func(t *testing.T) {
+		resp := tester.TestPostToolUse("Bash", &BashInput{Command: "make"}, "not an object")
+		errResp, ok := resp.(*ErrorResponse)
+		if !ok {
+			t.Fatalf("expected ErrorResponse, got %T", resp)
+		}
+		var decodeErr *DecodeError
+		if !errors.As(errResp.Error, &decodeErr) || decodeErr.Field != "tool_response" {
+			t.Errorf("expected tool_response DecodeError, got %v", errResp.Error)
+		}
+	})
+}
@@ -28,6 +28,17 @@ type WebSearchInput = tools.WebSearchInput
 type TaskInput = tools.TaskInput
 type ExitPlanModeInput = tools.ExitPlanModeInput
 
+// MCP tool types
+type MCPTool = tools.MCPTool
+type MCPToolOutput = tools.MCPToolOutput
+
+// ToolInput is a type constraint satisfied by every typed tool input
+type ToolInput interface {
+	BashInput | EditInput | MultiEditInput | WriteInput | ReadInput | GlobInput | GrepInput | LSInput |
+		TodoWriteInput | TodoReadInput | NotebookReadInput | NotebookEditInput | WebFetchInput |
+		WebSearchInput | TaskInput | ExitPlanModeInput
+}
+
 // Tool output types
 type BashOutput = tools.BashOutput
 type EditOutput = tools.EditOutput
@@ -37,6 +48,11 @@ type GrepOutput = tools.GrepOutput
 type LSOutput = tools.LSOutput
 type FileInfo = tools.FileInfo
 
+// ToolOutput is a type constraint satisfied by every typed tool output
+type ToolOutput interface {
+	BashOutput | EditOutput | ReadOutput | GlobOutput | GrepOutput | LSOutput
+}
+
 // Todo constants
 const (
 	TodoStatusPending    = tools.TodoStatusPending
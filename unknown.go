@@ -0,0 +1,39 @@
+package cchooks
+
+import (
+	"context"
+	"fmt"
+)
+
+// GenericEvent is passed to Runner.Unknown for events the SDK does not recognise
+// The event name is in HookEventName; the rest of the payload is available from
+// RawJSON, Extra and Field.
+type GenericEvent struct {
+	HookInput
+
+	payload
+}
+
+// UnknownEventPolicy decides what a Runner does with an unrecognised event when Unknown is not set
+type UnknownEventPolicy int
+
+const (
+	// UnknownEventPassThrough exits 0 without output, so new event types never block Claude Code
+	UnknownEventPassThrough UnknownEventPolicy = iota
+	// UnknownEventError reports the event to the Error handler and exits 2 by default
+	UnknownEventError
+)
+
+// unknownHandler handles an unrecognised event with the Unknown handler or the UnknownEvents policy
+func (r *Runner) unknownHandler(ctx context.Context, event *GenericEvent) interface{} {
+	if r.Unknown != nil {
+		if response := r.Unknown(ctx, event); response != nil {
+			return response
+		}
+		return nil
+	}
+	if r.UnknownEvents == UnknownEventError {
+		return Error(fmt.Errorf("unknown event type: %s", event.HookEventName))
+	}
+	return nil
+}